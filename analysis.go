@@ -4,7 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
@@ -23,13 +28,47 @@ var MyAnalyzer = &analysis.Analyzer{
 	RunDespiteErrors: true,
 	Run:              run,
 	Flags:            *flags,
+	FactTypes:        []analysis.Fact{new(annotationsFact)},
+	ResultType:       reflect.TypeOf(new(Result)),
 }
 
 var flags = flag.NewFlagSet("flags", flag.ExitOnError)
-var debugMode = flags.Bool("d", false, "enable debug mode")
+var inferMode = flags.Bool("infer", false, "record the types observed in empty interface{} slots without annotations, see the infer command")
+var explainPos = flags.String("explain", "", "print how the node at file:line:column is checked, see the explain command")
+var sealedMode = flags.Bool("sealed", false, "infer OneOf constraints for interfaces with unexported methods")
+var unverifiedMode = flags.String("unverified", "warn", "how to handle values of unannotated interface types assigned to annotated slots: warn, strict or trust")
+var ssaMode = flags.Bool("ssa", false, "check the dynamic types stored in interface{} values, tracked using SSA")
+var baselineFile = flags.String("baseline", "", "report only the violations that are not recorded in this baseline file")
+var writeBaseline = flags.Bool("write-baseline", false, "record the violations of the analyzed packages in the -baseline file")
+var pruneBaseline = flags.Bool("prune-baseline", false, "remove the entries of the analyzed packages that no longer match a violation from the -baseline file")
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	analyzer := NewAnalyzer(pass)
+	switch *unverifiedMode {
+	case "warn", "strict", "trust":
+	default:
+		return nil, fmt.Errorf("invalid -unverified value %q, want warn, strict or trust", *unverifiedMode)
+	}
+	if (*writeBaseline || *pruneBaseline) && *baselineFile == "" {
+		return nil, fmt.Errorf("-write-baseline and -prune-baseline require -baseline")
+	}
+	if *baselineFile != "" && rootPaths == nil {
+		// e.g. go vet, which runs one process per package, in the
+		// directory of the package
+		return nil, fmt.Errorf("-baseline is only supported by the driver of the intertype command, e.g. not by go vet or with -fix")
+	}
+
+	analyzer, err := NewAnalyzer(pass)
+	if err != nil {
+		return nil, err
+	}
+	analyzer.ImportFacts()
+	if *explainPos != "" {
+		explain, err := analyzer.newExplainer(*explainPos)
+		if err != nil {
+			return nil, err
+		}
+		analyzer.explain = explain
+	}
 
 	for _, f := range pass.Files {
 		for _, cg := range f.Comments {
@@ -45,43 +84,168 @@ func run(pass *analysis.Pass) (interface{}, error) {
 					continue
 				}
 
-				if ifaceNode.Methods.NumFields() != 0 {
+				if typeSpecNode.Assign.IsValid() {
+					pass.Reportf(comment.Pos(), "invalid annotation: cannot annotate an alias of unnamed type %s", pass.TypesInfo.TypeOf(ifaceNode))
 					continue
 				}
 
 				typeSpecType := pass.TypesInfo.Defs[typeSpecNode.Name].Type()
 				// analyzer.Add(typeSpecType, comment.Text)
-				analyzer.AddAsExt(typeSpecType, comment.Text)
+				if err := analyzer.AddAsExt(typeSpecType, comment); err != nil {
+					pass.Reportf(comment.Pos(), "invalid annotation: %v", err)
+				}
 			}
 		}
 	}
 
-	// fmt.Println(analyzer)
-	// fmt.Println("--------------")
+	var aliases [][2]types.Type
 
-	// DONE: use Underlying for the container? No.
 	for _, f := range pass.Files {
 		ast.Inspect(f, func(n ast.Node) bool {
-
-			if n != nil {
-				verbose := false
-				if verbose {
-					var pos token.Pos = n.Pos()
-					fmt.Printf("ALL %v %T %#+v\n",
-						Path(pass.Fset.Position(pos)),
-						n,
-						n,
-					)
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				fn, _ := pass.TypesInfo.Defs[n.Name].(*types.Func)
+				analyzer.AddFuncDirectives(fn, n.Doc)
+			case *ast.GenDecl:
+				if n.Tok != token.TYPE {
+					break
+				}
+				for _, spec := range n.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					doc := typeSpec.Doc
+					if doc == nil && !n.Lparen.IsValid() {
+						doc = n.Doc
+					}
+					obj := pass.TypesInfo.Defs[typeSpec.Name]
+					if obj == nil {
+						continue
+					}
+					typ := obj.Type()
+					if typeSpec.Assign.IsValid() {
+						typ = pass.TypesInfo.TypeOf(typeSpec.Type)
+						aliases = append(aliases, [2]types.Type{obj.Type(), typ})
+					}
+					analyzer.AddTypeDirectives(typ, doc, typeSpec.Comment)
+					if structNode, ok := typeSpec.Type.(*ast.StructType); ok {
+						analyzer.AddFieldDirectives(obj.Type(), structNode)
+					}
 				}
 			}
-
-			for i := range analyzer.Passes {
-				analyzer.Passes[i].Pass(analyzer, pass.TypesInfo, pass.Fset, n, f)
-			}
-
 			return true
 		})
 	}
 
-	return nil, nil
+	if *sealedMode {
+		analyzer.InferSealedInterfaces()
+	}
+
+	for i := range aliases {
+		analyzer.AddAlias(aliases[i][0], aliases[i][1])
+	}
+
+	if *ssaMode && analyzer.isRoot() {
+		analyzer.BuildSSA()
+	}
+
+	analyzer.InheritMethodAnnotations()
+	analyzer.TrackFuncValues()
+	analyzer.Summarize()
+	analyzer.ExportFacts()
+
+	// fmt.Println(analyzer)
+	// fmt.Println("--------------")
+
+	analyzer.CollectSuppressions()
+	if err := analyzer.LoadBaseline(); err != nil {
+		return nil, err
+	}
+	analyzer.runPasses()
+	analyzer.ReportFuncEscapes()
+	analyzer.ReportUnusedSuppressions()
+	analyzer.reportDiagnostics()
+	analyzer.PrintExplanation()
+	if err := analyzer.SaveBaseline(); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Findings:     analyzer.findings,
+		Diagnostics:  analyzer.reported,
+		Observations: analyzer.observations,
+	}, nil
+}
+
+// rootPaths are the import paths of the packages given to the command,
+// whose diagnostics are reported, unlike the ones of their dependencies,
+// which are only analyzed for the annotations they export. It is nil if
+// the driver does not set them.
+var rootPaths map[string]bool
+
+// SetRootPackages sets the import paths of the packages whose diagnostics
+// are reported. It is called by the driver of the intertype command before
+// the analysis.
+func SetRootPackages(paths []string) {
+	rootPaths = make(map[string]bool)
+	for _, path := range paths {
+		rootPaths[path] = true
+	}
+}
+
+// isRoot reports whether the analyzed package is one of the packages given
+// to the command, rather than a dependency of them, e.g. in the standard
+// library. Without the driver of the intertype command, the packages in
+// the working directory are, except for the standard library, as go vet
+// runs the analyzer in the directory of each package it analyzes.
+func (an *Analyzer) isRoot() bool {
+	pass := an.AnalysisPass
+	if rootPaths == nil {
+		if len(pass.Files) == 0 {
+			return false
+		}
+		filename := pass.Fset.Position(pass.Files[0].Pos()).Filename
+		return isInWorkDir(filename) && !isInGoroot(filename)
+	}
+	return rootPaths[pass.Pkg.Path()]
+}
+
+func isInGoroot(filename string) bool {
+	rel, err := filepath.Rel(filepath.Join(build.Default.GOROOT, "src"), filename)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// runPasses walks the files of the analyzed package with all the passes.
+func (an *Analyzer) runPasses() {
+	for _, f := range an.AnalysisPass.Files {
+		an.walk(f, f)
+	}
+}
+
+// walk walks root, a node of f, with all the passes.
+func (an *Analyzer) walk(f *ast.File, root ast.Node) {
+	pass := an.AnalysisPass
+
+	// DONE: use Underlying for the container? No.
+	ast.Inspect(root, func(n ast.Node) bool {
+
+		if n != nil {
+			verbose := false
+			if verbose {
+				var pos token.Pos = n.Pos()
+				fmt.Printf("ALL %v %T %#+v\n",
+					Path(pass.Fset.Position(pos)),
+					n,
+					n,
+				)
+			}
+		}
+
+		for i := range an.Passes {
+			p := an.Passes[i]
+			an.explainPass(p, n, func() {
+				p.Pass(an, pass.TypesInfo, pass.Fset, n, f)
+			})
+		}
+
+		return true
+	})
 }
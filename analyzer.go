@@ -1,10 +1,15 @@
 package intertype
 
 import (
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
 	"go/types"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
@@ -15,6 +20,10 @@ type MultiChecker interface {
 	MultiCheckAssign(spec *Constraints, lhsTyps, rhsTyps []types.Type) error
 }
 
+type ValueChecker interface {
+	CheckSwitchValues(spec *Constraints, lhs types.Type, caseValues []constant.Value, hasDefaultCase bool) error
+}
+
 type Checker interface {
 	CheckAssign(spec *Constraints, lhs, rhs types.Type) error
 	CheckSwitchTypes(spec *Constraints, lhs types.Type, switchTypes []types.Type, hasDefaultCase bool) error
@@ -23,36 +32,131 @@ type Checker interface {
 type YamlAnnotItem struct {
 	Address []string    `yaml:"address"`
 	Check   Constraints `yaml:"check"`
+
+	// Report customizes how violations are reported. It can also be set
+	// in Check, as comment annotations only have constraints.
+	Report Report `yaml:",inline"`
+
+	// Via is the flow path of an annotation derived for a function
+	// parameter, ending with the matcher it was derived from.
+	Via []Hop `yaml:"-"`
+
+	// Matcher and Source are the matcher the annotation was declared with
+	// and where, i.e. the "// #intertype" comment or the intertype.yaml
+	// entry. Pos is Source in the file set of the analysis it was declared
+	// in, for the related information of the diagnostics.
+	Matcher string         `yaml:"-"`
+	Source  token.Position `yaml:"-"`
+	Pos     token.Pos      `yaml:"-"`
+
+	// Sealed marks the OneOf constraint inferred for a sealed interface.
+	// Every implementer of the interface satisfies it, including the types
+	// of other packages that embed one of the listed types.
+	Sealed bool `yaml:"-"`
+}
+
+// Hop is a step of the flow path of a derived annotation: the parameter
+// Param of Func, used at Pos, or the matcher the annotation was derived
+// from, for the last step.
+type Hop struct {
+	Param   string
+	Func    string
+	Pos     token.Position
+	Matcher string
+}
+
+// String returns the step as written in the messages, with the file
+// relative to the working directory.
+func (hop Hop) String() string {
+	if hop.Matcher != "" {
+		return hop.Matcher
+	}
+	return fmt.Sprintf("param %s of %s at %s", hop.Param, hop.Func, Path(hop.Pos))
+}
+
+// hopsString returns the steps of a flow path separated by arrows.
+func hopsString(hops []Hop) string {
+	parts := make([]string, len(hops))
+	for i := range hops {
+		parts[i] = hops[i].String()
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// key identifies the declaration of the annotation.
+func (item YamlAnnotItem) key() string {
+	return fmt.Sprintf("%s\n%s\n%s", item.Matcher, item.Source, item.Check)
 }
 
-func ParseTypes(filename string) map[string][]YamlAnnotItem {
+func ParseTypes(filename string) (map[string][]YamlAnnotItem, error) {
 	result := make(map[string][]YamlAnnotItem)
 
 	f, err := os.Open(filename)
 	if err != nil {
-		return result
+		return result, nil
 		// panic(err)
 	}
 	defer f.Close()
 
 	byts, err := ioutil.ReadAll(f)
 	if err != nil {
-		return result
+		return result, nil
 		// panic(err)
 	}
 
 	err = yaml.Unmarshal(byts, &result)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%s: %v", filename, err)
 	}
-	return result
+
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		path = filename
+	}
+	lines := yamlKeyLines(byts)
+	for matcher, items := range result {
+		for i := range items {
+			items[i].Check.Report = items[i].Check.Report.Merge(items[i].Report)
+			items[i].Matcher = matcher
+			items[i].Source = token.Position{Filename: path, Line: lines[matcher], Column: 1}
+			if err := items[i].Check.Report.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: invalid annotation %q: %v", items[i].Source, matcher, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// yamlKeyLines returns the line numbers of the top-level keys of a yaml
+// document, as yaml.v2 does not report them.
+func yamlKeyLines(byts []byte) map[string]int {
+	lines := make(map[string]int)
+	for i, line := range strings.Split(string(byts), "\n") {
+		if line == "" || strings.ContainsRune(" \t#-", rune(line[0])) {
+			continue
+		}
+		var key map[string]interface{}
+		if err := yaml.Unmarshal([]byte(line), &key); err != nil {
+			continue
+		}
+		for k := range key {
+			lines[k] = i + 1
+		}
+	}
+	return lines
 }
 
-func NewAnalyzer(analysisPass *analysis.Pass) *Analyzer {
-	return &Analyzer{
+func NewAnalyzer(analysisPass *analysis.Pass) (*Analyzer, error) {
+	annots, err := ParseTypes("./intertype.yaml")
+	if err != nil {
+		return nil, err
+	}
+	setYamlPos(analysisPass.Fset, annots)
+	an := &Analyzer{
 		AnalysisPass: analysisPass,
 		Passes:       DefaultPasses,
-		Annots:       ParseTypes("./intertype.yaml"),
+		Annots:       annots,
+		Exports:      make(map[string][]YamlAnnotItem),
 		MultiCheckers: []MultiChecker{
 			&SameTypes{},
 		},
@@ -69,15 +173,50 @@ func NewAnalyzer(analysisPass *analysis.Pass) *Analyzer {
 			&NoneOfChecker{},
 			&TagsChecker{},
 		},
+		ValueCheckers: []ValueChecker{
+			&EnumChecker{},
+		},
+	}
+	for _, ch := range an.Checkers {
+		switch ch := ch.(type) {
+		case *OneOfChecker:
+			ch.Allowed = an.AllowedTypes
+			ch.Implements = an.ImplementsNamed
+			ch.Implementing = an.namesImplementing
+			ch.Overlap = an.namesOverlap
+		case *NoneOfChecker:
+			ch.Allowed = an.AllowedTypes
+			ch.Implements = an.ImplementsNamed
+			ch.Implementing = an.namesImplementing
+		}
 	}
+	return an, nil
 }
 
 type Analyzer struct {
 	AnalysisPass  *analysis.Pass
 	Passes        []Passer
 	Annots        map[string][]YamlAnnotItem
+	Exports       map[string][]YamlAnnotItem
 	Checkers      []Checker
 	MultiCheckers []MultiChecker
+	ValueCheckers []ValueChecker
+	SSA           *SSAInfo
+
+	params      map[*types.Var]paramRef
+	pkgs        map[string]*types.Package
+	derived     map[string]bool
+	summarizing bool
+
+	funcValues   map[*types.Var]*types.Func
+	funcEscapes  []funcEscape
+	diagnostics  []diagnostic
+	reported     []Diagnostic
+	suppressions []*suppression
+	baseline     *baselineState
+	findings     []Finding
+	explain      *explainer
+	observations []Observation
 }
 
 func (an *Analyzer) String() string {
@@ -93,113 +232,437 @@ func (an *Analyzer) String() string {
 	return builder.String()
 }
 
-func (an *Analyzer) AddAsExt(t types.Type, annotation string) {
+// AddAsExt adds the annotation of the interface type t declared in
+// comment. It returns an error if the annotation is malformed.
+func (an *Analyzer) AddAsExt(t types.Type, comment *ast.Comment) error {
 	// matcher := fmt.Sprintf("[] %s %s", t, t.Underlying())
 	matcher := fmt.Sprintf("[] %s", t)
-	constraint, err := parseIntertypeCommentLines([]string{annotation})
+	constraint, err := parseIntertypeCommentLines([]string{comment.Text})
 	if err != nil {
-		panic(err)
+		return err
 	}
 	if constraint == nil {
-		return
+		return nil
 	}
+	if err := constraint.Report.Validate(); err != nil {
+		return err
+	}
+	an.resolveTypeNames(comment.Pos(), constraint)
+
+	an.AddMatcher(matcher, *constraint, comment.Pos())
+	return nil
+}
 
-	checks := an.Annots[matcher]
-	checks = append(checks, YamlAnnotItem{
+// AddMatcher adds an annotation declared at pos in the analyzed package.
+// Unlike the annotations in intertype.yaml, it is exported to the
+// packages that import this package.
+func (an *Analyzer) AddMatcher(matcher string, constraint Constraints, pos token.Pos) {
+	an.addItems(matcher, YamlAnnotItem{
 		Address: []string{},
-		Check:   *constraint,
+		Check:   constraint,
+		Matcher: matcher,
+		Source:  an.AnalysisPass.Fset.Position(pos),
+		Pos:     pos,
 	})
-	an.Annots[matcher] = checks
+}
+
+func (an *Analyzer) addItems(matcher string, items ...YamlAnnotItem) {
+	an.Annots[matcher] = append(an.Annots[matcher], items...)
+	an.Exports[matcher] = append(an.Exports[matcher], items...)
 }
 
 func (an *Analyzer) CheckSwitchStmt(matcher string, lhsType types.Type, rhsType []types.Type, hasDefaultCase bool) error {
+	an.explainMatcher(matcher)
 	annotItems, found := an.Annots[matcher]
 	if !found {
-		if *debugMode {
-			fmt.Fprintf(os.Stderr, "NOTFOUND %q\n", matcher)
-		}
 		return nil
 	}
 
-	if *debugMode {
-		fmt.Fprintf(os.Stderr, "FOUND %q\n", matcher)
+	var errs Violations
+	for ii := range annotItems {
+		spec := annotItems[ii].sealedSpec(lhsType, rhsType)
+		err := an.CheckSwitchTypesSpec(lhsType, rhsType, hasDefaultCase, spec)
+		for _, err := range errorList(err) {
+			errs = append(errs, &annotationError{
+				err:       err,
+				item:      annotItems[ii],
+				dynamic:   typeNames(rhsType),
+				annotated: typeNames([]types.Type{lhsType}),
+			})
+		}
+	}
+	return errs.Err()
+}
+
+func (an *Analyzer) CheckValueSwitch(matcher string, lhsType types.Type, caseValues []constant.Value, hasDefaultCase bool) error {
+	an.explainMatcher(matcher)
+	annotItems, found := an.Annots[matcher]
+	if !found {
+		return nil
 	}
 
+	var errs Violations
 	for ii := range annotItems {
-		err := an.CheckSwitchTypesSpec(lhsType, rhsType, hasDefaultCase, annotItems[ii].Check)
-		if err != nil {
-			return err
+		spec := annotItems[ii].Check
+		if spec.Enum {
+			spec.EnumMembers = an.enumCases(lhsType, spec.EnumMembers)
 		}
+		for _, ch := range an.ValueCheckers {
+			err := ch.CheckSwitchValues(&spec, lhsType, caseValues, hasDefaultCase)
+			an.explainVerdict(ch, err)
+			if err != nil {
+				errs = append(errs, &annotationError{
+					err:       err,
+					item:      annotItems[ii],
+					dynamic:   typeNames([]types.Type{lhsType}),
+					annotated: typeNames([]types.Type{lhsType}),
+				})
+			}
+		}
+		an.explainChecked(typeNames([]types.Type{lhsType}), fmt.Sprintf("cases %v", caseValues))
 	}
-	return nil
+	return errs.Err()
 }
 
 func (an *Analyzer) CheckMatcher(matcher string, lhsType, rhsType types.Type) error {
+	an.explainMatcher(matcher)
 	annotItems, found := an.Annots[matcher]
 	if !found {
-		if *debugMode {
-			fmt.Fprintf(os.Stderr, "NOTFOUND %q\n", matcher)
-		}
 		return nil
 	}
-	if *debugMode {
-		fmt.Fprintf(os.Stderr, "FOUND %q\n", matcher)
-	}
 
+	var errs Violations
+	violated := make(map[string]bool)
 	for ii := range annotItems {
-		err := an.checkAssignWithSpec(lhsType, rhsType, annotItems[ii].Check)
-		if err != nil {
-			return err
+		// an annotation inherited or derived along several paths is
+		// reported once
+		key := annotItems[ii].key()
+		if violated[key] {
+			continue
+		}
+		spec := annotItems[ii].sealedSpec(lhsType, []types.Type{rhsType})
+		err := an.checkAssignWithSpec(lhsType, rhsType, spec)
+		if err != nil && annotItems[ii].Matcher != "" {
+			violated[key] = true
+		}
+		for _, err := range errorList(err) {
+			errs = append(errs, &annotationError{
+				err:       err,
+				item:      annotItems[ii],
+				dynamic:   typeNames([]types.Type{rhsType}),
+				annotated: typeNames([]types.Type{lhsType}),
+			})
 		}
 	}
-	return nil
+	return errs.Err()
 }
 
-func (an *Analyzer) CheckMatcherMultiple(matcher string, lhsTypes, rhsTypes []types.Type) error {
+// AllowedTypes returns the types that values of the interface type typ
+// can contain, if it is annotated with OneOf.
+func (an *Analyzer) AllowedTypes(typ types.Type) ([]string, bool) {
+	if typ == nil || !types.IsInterface(typ) {
+		return nil, false
+	}
+	var allowed []string
+	found := false
+	for _, item := range an.Annots[fmt.Sprintf("[] %s", typ)] {
+		if len(item.Check.OneOf) == 0 {
+			continue
+		}
+		if !found {
+			allowed, found = item.Check.OneOf, true
+			continue
+		}
+		// all the annotations hold
+		allowed = typesIn(allowed, item.Check.OneOf)
+	}
+	return allowed, found
+}
+
+// CheckTypeAssert checks the assertion to rhsType of a value of lhsType,
+// annotated by the items of matcher. If both are interface types
+// annotated with OneOf, the assertion is impossible when none of the
+// types that lhsType can contain is allowed in rhsType, and the other
+// constraints are checked as for assignments.
+func (an *Analyzer) CheckTypeAssert(matcher string, lhsType, rhsType types.Type) error {
+	asserted, ok := an.AllowedTypes(rhsType)
+	if !ok || types.Identical(lhsType, rhsType) {
+		return an.CheckMatcher(matcher, lhsType, rhsType)
+	}
+
+	an.explainMatcher(matcher)
+	annTypeStr := typeNames([]types.Type{lhsType})
+	dynTypeStr := typeNames([]types.Type{rhsType})
+	var errs Violations
+	for _, item := range an.Annots[matcher] {
+		if len(item.Check.OneOf) > 0 && !an.namesOverlap(item.Check.OneOf, asserted) {
+			errs = append(errs, &annotationError{
+				err: fmt.Errorf("impossible type assertion: %s cannot contain any of the dynamic types %s of %s, allowed types: %s",
+					annTypeStr, strings.Join(asserted, ", "), dynTypeStr, strings.Join(item.Check.OneOf, ", ")),
+				item:      item,
+				dynamic:   dynTypeStr,
+				annotated: annTypeStr,
+			})
+		}
+
+		spec := item.Check
+		spec.OneOf = nil
+		for _, err := range errorList(an.checkAssignWithSpec(lhsType, rhsType, spec)) {
+			errs = append(errs, &annotationError{
+				err:       err,
+				item:      item,
+				dynamic:   dynTypeStr,
+				annotated: annTypeStr,
+			})
+		}
+	}
+	return errs.Err()
+}
+
+// CheckMatcherExpr is like CheckMatcher, but when rhs is an interface
+// value whose dynamic types are known from the SSA form, those types are
+// checked instead of the static type rhsType. rhs may be nil.
+func (an *Analyzer) CheckMatcherExpr(matcher string, lhsType, rhsType types.Type, rhs ast.Expr) error {
+	if an.deriveParam(matcher, rhs) {
+		// checked at the call sites instead
+		return nil
+	}
+	if arg, ok := assertArg(an.AnalysisPass.TypesInfo, rhs); ok {
+		argType := an.AnalysisPass.TypesInfo.TypeOf(arg)
+		if argType == nil || types.IsInterface(argType) {
+			// the caller vouches for the dynamic type
+			return nil
+		}
+		// the type of a concrete value is known, and checked as is
+		rhs, rhsType = arg, argType
+	}
+	dynTypes := an.DynamicTypes(rhs, rhsType)
+	if len(dynTypes) != 1 || dynTypes[0] != rhsType {
+		an.explainf("dynamic types of %s from SSA: %s", typeNames([]types.Type{rhsType}), typeNames(dynTypes))
+	}
+	var errs Violations
+	for _, typ := range dynTypes {
+		an.observe(matcher, lhsType, typ, rhs)
+		if an.isUnverified(typ) {
+			if err := an.checkUnverified(matcher, lhsType, typ); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, err := range errorList(an.CheckMatcher(matcher, lhsType, typ)) {
+			if rhs != nil {
+				err = &nodeError{err: err, node: rhs}
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errs.Err()
+}
+
+// isUnverified reports whether the dynamic type of values of type typ is
+// unknown, i.e. typ is an interface type without annotations of its own.
+func (an *Analyzer) isUnverified(typ types.Type) bool {
+	if typ == nil || !types.IsInterface(typ) {
+		return false
+	}
+	_, annotated := an.Annots[fmt.Sprintf("[] %s", typ)]
+	return !annotated
+}
+
+// checkUnverified checks a value of the unannotated interface type rhsType
+// assigned to an annotated slot, according to the -unverified flag:
+//
+//   - warn: report a warning if the check fails for rhsType itself, or if
+//     rhsType is the type of the slot, which any dynamic type passes
+//   - strict: report an error, unless the value is type asserted or passed
+//     through intertype.Assert first
+//   - trust: do not report anything
+func (an *Analyzer) checkUnverified(matcher string, lhsType, rhsType types.Type) error {
 	annotItems, found := an.Annots[matcher]
 	if !found {
-		if *debugMode {
-			fmt.Fprintf(os.Stderr, "NOTFOUND %q\n", matcher)
-		}
 		return nil
 	}
 
-	if *debugMode {
-		fmt.Fprintf(os.Stderr, "FOUND %q\n", matcher)
+	annTypeStr := typeNames([]types.Type{lhsType})
+	dynTypeStr := typeNames([]types.Type{rhsType})
+
+	switch *unverifiedMode {
+	case "trust":
+		return nil
+	case "strict":
+		return &annotationError{
+			err:       fmt.Errorf("unverified: source is %s, assert its dynamic type before using it as %s", dynTypeStr, annTypeStr),
+			item:      annotItems[0],
+			dynamic:   dynTypeStr,
+			annotated: annTypeStr,
+			own:       true,
+			category:  "unverified",
+		}
 	}
 
+	var item YamlAnnotItem
+	if types.Identical(lhsType, rhsType) {
+		// the checks pass for the slot's own type, e.g. interface{} in
+		// append(keys, vs...), whatever the dynamic type is
+		found := false
+		for _, annotItem := range annotItems {
+			if len(annotItem.Check.OneOf) > 0 || len(annotItem.Check.NoneOf) > 0 {
+				item, found = annotItem, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	} else {
+		errs := errorList(an.CheckMatcher(matcher, lhsType, rhsType))
+		if len(errs) == 0 {
+			return nil
+		}
+		var annotErr *annotationError
+		errors.As(errs[0], &annotErr)
+		item = annotErr.item
+	}
+	return &annotationError{
+		err:       fmt.Errorf("unverified: source is %s, assert its dynamic type before using it as %s", dynTypeStr, annTypeStr),
+		item:      item,
+		dynamic:   dynTypeStr,
+		annotated: annTypeStr,
+		own:       true,
+		category:  "unverified",
+		severity:  "warning",
+	}
+}
+
+func (an *Analyzer) CheckMatcherMultiple(matcher string, lhsTypes, rhsTypes []types.Type) error {
+	an.explainMatcher(matcher)
+	annotItems, found := an.Annots[matcher]
+	if !found {
+		return nil
+	}
+
+	var errs Violations
 	for ii := range annotItems {
 		err := an.checkAssignWithSpecMultiple(lhsTypes, rhsTypes, annotItems[ii].Check)
 		if err != nil {
-			return err
+			errs = append(errs, &annotationError{
+				err:       err,
+				item:      annotItems[ii],
+				dynamic:   typeNames(rhsTypes),
+				annotated: typeNames(lhsTypes),
+			})
 		}
 	}
-	return nil
+	return errs.Err()
 }
 
 func (an *Analyzer) checkAssignWithSpecMultiple(annotatedTypes, dynTypes []types.Type, spec Constraints) error {
 	for _, ch := range an.MultiCheckers {
-		if err := ch.MultiCheckAssign(&spec, annotatedTypes, dynTypes); err != nil {
-			return fmt.Errorf("%v", err)
+		err := ch.MultiCheckAssign(&spec, annotatedTypes, dynTypes)
+		an.explainVerdict(ch, err)
+		if err != nil {
+			an.explainChecked(typeNameList(annotatedTypes), typeNameList(dynTypes))
+			return fmt.Errorf("%w", err)
 		}
 	}
+	an.explainChecked(typeNameList(annotatedTypes), typeNameList(dynTypes))
 	return nil
 }
 
+// checkAssignWithSpec checks the assignment of a value of rhs to lhs. If
+// rhs is an interface type annotated with OneOf, OneOfChecker and
+// NoneOfChecker compare the annotations, and the other checkers check
+// each of the types it allows.
 func (an *Analyzer) checkAssignWithSpec(lhs, rhs types.Type, spec Constraints) error {
+	allowed, subsumed := an.AllowedTypes(rhs)
+	subsumed = subsumed && !types.Identical(lhs, rhs)
+
+	var errs Violations
 	for _, ch := range an.Checkers {
-		if err := ch.CheckAssign(&spec, lhs, rhs); err != nil {
-			return fmt.Errorf("%v", err)
+		switch ch.(type) {
+		case *OneOfChecker, *NoneOfChecker:
+		default:
+			if subsumed {
+				var chErrs Violations
+				for _, name := range allowed {
+					typ := an.LookupType(name)
+					if typ == nil {
+						continue
+					}
+					if err := ch.CheckAssign(&spec, lhs, typ); err != nil {
+						chErrs = append(chErrs, fmt.Errorf("%w", err))
+					}
+				}
+				an.explainVerdict(ch, chErrs.Err())
+				errs = append(errs, chErrs...)
+				continue
+			}
+		}
+		err := ch.CheckAssign(&spec, lhs, rhs)
+		an.explainVerdict(ch, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w", err))
 		}
 	}
-	return nil
+	an.explainChecked(typeNames([]types.Type{lhs}), typeNames([]types.Type{rhs}))
+	return errs.Err()
 }
 
 func (an *Analyzer) CheckSwitchTypesSpec(lhs types.Type, switchTypes []types.Type, hasDefaultCase bool, spec Constraints) error {
+	var errs Violations
 	for _, ch := range an.Checkers {
-		if err := ch.CheckSwitchTypes(&spec, lhs, switchTypes, hasDefaultCase); err != nil {
-			return fmt.Errorf("%v", err)
+		err := ch.CheckSwitchTypes(&spec, lhs, switchTypes, hasDefaultCase)
+		an.explainVerdict(ch, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w", err))
 		}
 	}
-	return nil
+	an.explainChecked(typeNames([]types.Type{lhs}), "cases "+typeNameList(switchTypes))
+	return errs.Err()
+}
+
+// Violations collects the errors of all the checkers and annotation items
+// that fail for a site, so that they are reported together rather than
+// one per run.
+type Violations []error
+
+func (errs Violations) Error() string {
+	msgs := make([]string, len(errs))
+	for i := range errs {
+		msgs[i] = errs[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Err returns nil if there are no violations, the only one if there is
+// one, and errs otherwise.
+func (errs Violations) Err() error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
+
+// errorList returns the violations in err, which may be nil.
+func errorList(err error) []error {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case Violations:
+		var list []error
+		for i := range err {
+			list = append(list, errorList(err[i])...)
+		}
+		return list
+	case *nodeError:
+		list := errorList(err.err)
+		for i := range list {
+			list[i] = &nodeError{err: list[i], node: err.node}
+		}
+		return list
+	}
+	return []error{err}
 }
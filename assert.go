@@ -0,0 +1,24 @@
+package intertype
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const assertFuncName = "github.com/siadat/intertype/assert.Assert"
+
+// assertArg returns the argument of expr if it is a call to
+// assert.Assert.
+func assertArg(typesInfo *types.Info, expr ast.Expr) (ast.Expr, bool) {
+	call, ok := unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, false
+	}
+	fn, ok := typeutil.Callee(typesInfo, call).(*types.Func)
+	if !ok || fn.FullName() != assertFuncName {
+		return nil, false
+	}
+	return call.Args[0], true
+}
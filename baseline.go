@@ -0,0 +1,228 @@
+package intertype
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"gopkg.in/yaml.v2"
+)
+
+// BaselineEntry is a violation recorded in a baseline file. It is keyed
+// by what is unlikely to change when unrelated code is edited, rather
+// than by line numbers.
+type BaselineEntry struct {
+	Matcher string `yaml:"matcher"`
+	File    string `yaml:"file"`
+	Func    string `yaml:"func,omitempty"`
+	Snippet string `yaml:"snippet"`
+}
+
+// Baseline maps package paths to their recorded violations. An entry
+// listed n times accepts n violations.
+type Baseline map[string][]BaselineEntry
+
+// baselineMu serializes the updates of the baseline file by the analysis
+// of different packages.
+var baselineMu sync.Mutex
+
+type baselineState struct {
+	remaining map[BaselineEntry]int
+	matched   []BaselineEntry
+	current   []BaselineEntry
+	sources   map[string][]string
+}
+
+func ReadBaseline(filename string) (Baseline, error) {
+	baseline := make(Baseline)
+	byts, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(byts, &baseline); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %v", filename, err)
+	}
+	return baseline, nil
+}
+
+func WriteBaseline(filename string, baseline Baseline) error {
+	for pkg := range baseline {
+		if len(baseline[pkg]) == 0 {
+			delete(baseline, pkg)
+			continue
+		}
+		entries := baseline[pkg]
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Func != b.Func {
+				return a.Func < b.Func
+			}
+			if a.Snippet != b.Snippet {
+				return a.Snippet < b.Snippet
+			}
+			return a.Matcher < b.Matcher
+		})
+	}
+	return ioutil.WriteFile(filename, []byte(MustMarshalYaml(baseline)), 0644)
+}
+
+// LoadBaseline reads the entries of the analyzed package from the
+// -baseline file.
+func (an *Analyzer) LoadBaseline() error {
+	if *baselineFile == "" {
+		return nil
+	}
+
+	if !an.isRoot() {
+		// its diagnostics are not reported
+		return nil
+	}
+
+	baselineMu.Lock()
+	baseline, err := ReadBaseline(*baselineFile)
+	baselineMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	an.baseline = &baselineState{
+		remaining: make(map[BaselineEntry]int),
+		sources:   make(map[string][]string),
+	}
+	for _, entry := range baseline[an.AnalysisPass.Pkg.Path()] {
+		an.baseline.remaining[entry]++
+	}
+	return nil
+}
+
+// baselined reports whether the violation err at pos is recorded in the
+// baseline. With -write-baseline, all violations are.
+func (an *Analyzer) baselined(pos token.Pos, err error) bool {
+	if an.baseline == nil {
+		return false
+	}
+
+	entry := an.baselineEntry(pos, err)
+	an.baseline.current = append(an.baseline.current, entry)
+
+	if *writeBaseline {
+		return true
+	}
+	if an.baseline.remaining[entry] == 0 {
+		return false
+	}
+	an.baseline.remaining[entry]--
+	an.baseline.matched = append(an.baseline.matched, entry)
+	return true
+}
+
+// SaveBaseline records the violations of the analyzed package with
+// -write-baseline, or removes the entries that no longer match any with
+// -prune-baseline. The entries of other packages are kept.
+func (an *Analyzer) SaveBaseline() error {
+	if an.baseline == nil || !(*writeBaseline || *pruneBaseline) {
+		return nil
+	}
+
+	baselineMu.Lock()
+	defer baselineMu.Unlock()
+
+	baseline, err := ReadBaseline(*baselineFile)
+	if err != nil {
+		return err
+	}
+	if *writeBaseline {
+		baseline[an.AnalysisPass.Pkg.Path()] = an.baseline.current
+	} else {
+		baseline[an.AnalysisPass.Pkg.Path()] = an.baseline.matched
+	}
+	return WriteBaseline(*baselineFile, baseline)
+}
+
+func (an *Analyzer) baselineEntry(pos token.Pos, err error) BaselineEntry {
+	position := an.AnalysisPass.Fset.Position(pos)
+
+	entry := BaselineEntry{
+		Matcher: err.Error(),
+		File:    filepath.ToSlash(position.Filename),
+		Snippet: an.snippet(position),
+	}
+
+	var annotErr *annotationError
+	if errors.As(err, &annotErr) && annotErr.item.Matcher != "" {
+		entry.Matcher = annotErr.item.Matcher
+	}
+
+	if rel, ok := workDirRel(position.Filename); ok {
+		entry.File = rel
+	}
+
+	if file := an.fileOf(pos); file != nil {
+		path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+		for _, node := range path {
+			if decl, ok := node.(*ast.FuncDecl); ok {
+				entry.Func = funcDeclName(decl)
+				break
+			}
+		}
+	}
+
+	return entry
+}
+
+// workDirRel returns filename relative to the working directory, which
+// the files of the baseline are relative to, like intertype.yaml.
+func workDirRel(filename string) (string, bool) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func isInWorkDir(filename string) bool {
+	rel, ok := workDirRel(filename)
+	return ok && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// snippet returns the source line at position with its spaces normalized.
+func (an *Analyzer) snippet(position token.Position) string {
+	lines, ok := an.baseline.sources[position.Filename]
+	if !ok {
+		byts, _ := ioutil.ReadFile(position.Filename)
+		lines = strings.Split(string(byts), "\n")
+		an.baseline.sources[position.Filename] = lines
+	}
+	if position.Line < 1 || position.Line > len(lines) {
+		return ""
+	}
+	return strings.Join(strings.Fields(lines[position.Line-1]), " ")
+}
+
+// funcDeclName returns the name of a function, e.g. "Store" or
+// "(*Registry).Store".
+func funcDeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	return fmt.Sprintf("(%s).%s", types.ExprString(decl.Recv.List[0].Type), decl.Name.Name)
+}
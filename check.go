@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,6 +34,57 @@ type Constraints struct {
 	IsPointer    bool              `yaml:"IsPointer,omitempty" json:"IsPointer,omitempty"`
 	IsReference  bool              `yaml:"Reference,omitempty" json:"Reference,omitempty"`
 	IsNotPointer bool              `yaml:"IsNotPointer,omitempty" json:"IsNotPointer,omitempty"`
+	Enum         bool              `yaml:"Enum,omitempty" json:"Enum,omitempty"`
+
+	// EnumMembers are the constants of an Enum type,
+	// collected from the package that declares it.
+	EnumMembers []EnumMember `yaml:"-" json:"-"`
+
+	Report `yaml:",inline" json:"-"`
+}
+
+// Report customizes the diagnostics of an annotation:
+//
+//	{OneOf: [string], severity: warning, message: "use string keys, got {dynamic}", category: ctxkeys}
+//
+// message may refer to the dynamic and annotated types as {dynamic} and
+// {annotated}.
+type Report struct {
+	Severity string `yaml:"severity,omitempty"`
+	Message  string `yaml:"message,omitempty"`
+	Category string `yaml:"category,omitempty"`
+	DocURL   string `yaml:"docURL,omitempty"`
+}
+
+// Merge returns r with the fields set in other overriding its own.
+func (r Report) Merge(other Report) Report {
+	if other.Severity != "" {
+		r.Severity = other.Severity
+	}
+	if other.Message != "" {
+		r.Message = other.Message
+	}
+	if other.Category != "" {
+		r.Category = other.Category
+	}
+	if other.DocURL != "" {
+		r.DocURL = other.DocURL
+	}
+	return r
+}
+
+// Validate returns an error if the severity of r is not known.
+func (r Report) Validate() error {
+	switch r.Severity {
+	case "", "error", "warning", "info":
+		return nil
+	}
+	return fmt.Errorf("unknown severity %q, want error, warning or info", r.Severity)
+}
+
+type EnumMember struct {
+	Name  string
+	Value string
 }
 
 func MustMarshalYaml(whatever interface{}) string {
@@ -87,7 +140,7 @@ func (*SameTypes) MultiCheckAssign(spec *Constraints, lhsTyps, rhsTyps []types.T
 	for i := range indexes {
 		idx := indexes[i]
 		if !types.Identical(rhsTyps[indexes[0]], rhsTyps[idx]) {
-			return fmt.Errorf("expected same types, got %s != %s", rhsTyps[0], rhsTyps[idx])
+			return fmt.Errorf("expected same types, got %s != %s", typeString(rhsTyps[0]), typeString(rhsTyps[idx]))
 		}
 	}
 	return nil
@@ -118,7 +171,16 @@ func (ch *IsPointer) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a pointer, got %s", rhs)
+	return &NotPointerError{Got: rhs}
+}
+
+// NotPointerError is returned by IsPointer for values that are not pointers.
+type NotPointerError struct {
+	Got types.Type
+}
+
+func (err *NotPointerError) Error() string {
+	return fmt.Sprintf("expected a pointer, got %s", typeString(err.Got))
 }
 
 // ---
@@ -147,7 +209,7 @@ func (ch *IsInterface) CheckAssign(spec *Constraints, lhs, rhs types.Type) error
 		return nil
 	}
 
-	return fmt.Errorf("expected an interface, got %s", rhs)
+	return fmt.Errorf("expected an interface, got %s", typeString(rhs))
 }
 
 // --
@@ -176,7 +238,7 @@ func (ch *IsChan) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a channel, got %s", rhs)
+	return fmt.Errorf("expected a channel, got %s", typeString(rhs))
 }
 
 //
@@ -204,7 +266,7 @@ func (ch *IsStruct) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a struct, got %s", rhs)
+	return fmt.Errorf("expected a struct, got %s", typeString(rhs))
 }
 
 //
@@ -233,7 +295,7 @@ func (ch *IsMap) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a map, got %s", rhs)
+	return fmt.Errorf("expected a map, got %s", typeString(rhs))
 }
 
 ///
@@ -262,7 +324,7 @@ func (ch *IsSlice) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a slice, got %s", rhs)
+	return fmt.Errorf("expected a slice, got %s", typeString(rhs))
 }
 
 // --
@@ -291,7 +353,7 @@ func (ch *IsFunc) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
 		return nil
 	}
 
-	return fmt.Errorf("expected a function, got %s", rhs)
+	return fmt.Errorf("expected a function, got %s", typeString(rhs))
 }
 
 type TagsChecker struct{}
@@ -361,7 +423,26 @@ func (ch *TagsChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) error
 		return missingFieldsSlice[i] < missingFieldsSlice[j]
 	})
 
-	return fmt.Errorf("missing tags %s of %s", strings.Join(missingFieldsSlice, ", "), rhs)
+	return &MissingTagsError{
+		Typ:     rhs,
+		Missing: missingFieldsTags,
+		msg:     fmt.Sprintf("missing tags %s of %s", strings.Join(missingFieldsSlice, ", "), typeString(rhs)),
+	}
+}
+
+// MissingTagsError is returned by TagsChecker for structs with fields
+// missing some of the required tags.
+type MissingTagsError struct {
+	Typ types.Type
+
+	// Missing are the missing tags, by field name.
+	Missing map[string][]string
+
+	msg string
+}
+
+func (err *MissingTagsError) Error() string {
+	return err.msg
 }
 
 type FieldsChecker struct{}
@@ -432,11 +513,90 @@ func (ch *FieldsChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) err
 		return missingFieldsSlice[i] < missingFieldsSlice[j]
 	})
 
-	return fmt.Errorf("missing fields [%s] in %s", strings.Join(missingFieldsSlice, ", "), rhs)
+	return fmt.Errorf("missing fields [%s] in %s", strings.Join(missingFieldsSlice, ", "), typeString(rhs))
+}
+
+type EnumChecker struct{}
+
+func (ch *EnumChecker) CheckSwitchValues(spec *Constraints, lhs types.Type, caseValues []constant.Value, hasDefaultCase bool) error {
+	if !spec.Enum || hasDefaultCase {
+		return nil
+	}
+
+	covered := make(map[string]bool)
+	for i := range caseValues {
+		if caseValues[i] != nil {
+			covered[caseValues[i].ExactString()] = true
+		}
+	}
+
+	var missingCases []string
+	for _, member := range spec.EnumMembers {
+		if !covered[member.Value] {
+			missingCases = append(missingCases, member.Name)
+		}
+	}
+
+	if len(missingCases) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("missing cases %v", missingCases)
 }
 
-type OneOfChecker struct{}
-type NoneOfChecker struct{}
+// OneOfChecker and NoneOfChecker compare the constraints of the source
+// rather than its type, if it is an interface type annotated with OneOf,
+// whose types are returned by Allowed.
+//
+// Implements reports whether a type implements the interface type named
+// by an entry, e.g. "error", which then matches it too, and Implementing
+// returns the types named in names that do. Overlap reports whether a
+// value can have one of the types named in both, for the cases of type
+// switches that are annotated interface types.
+type OneOfChecker struct {
+	Allowed      func(types.Type) ([]string, bool)
+	Implements   func(typ types.Type, name string) bool
+	Implementing func(names, ifaceNames []string) []string
+	Overlap      func(a, b []string) bool
+}
+type NoneOfChecker struct {
+	Allowed      func(types.Type) ([]string, bool)
+	Implements   func(typ types.Type, name string) bool
+	Implementing func(names, ifaceNames []string) []string
+}
+
+// implementsAny reports whether typ implements one of the interface types
+// named by names.
+func implementsAny(implements func(types.Type, string) bool, typ types.Type, names []string) bool {
+	if implements == nil {
+		return false
+	}
+	for _, name := range names {
+		if implements(typ, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutImplementing returns the types of impossible that do not
+// implement one of the interface types named by names, among typs.
+func withoutImplementing(implements func(types.Type, string) bool, impossible []string, typs []types.Type, names []string) []string {
+	var kept []string
+	for _, name := range impossible {
+		implemented := false
+		for _, typ := range typs {
+			if typ != nil && typ.String() == name && implementsAny(implements, typ, names) {
+				implemented = true
+				break
+			}
+		}
+		if !implemented {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
 
 func (ch *OneOfChecker) CheckSwitchTypes(spec *Constraints, lhs types.Type, switchTypes []types.Type, hasDefaultCase bool) error {
 	if len(spec.OneOf) == 0 {
@@ -444,22 +604,46 @@ func (ch *OneOfChecker) CheckSwitchTypes(spec *Constraints, lhs types.Type, swit
 	}
 
 	missingTyps, impossibleTyps := checkPossibleTypes(spec.OneOf, switchTypes)
+	impossibleTyps = withoutImplementing(ch.Implements, impossibleTyps, switchTypes, spec.OneOf)
+	impossibleTyps = ch.withoutOverlapping(impossibleTyps, switchTypes, spec.OneOf)
 
-	var errParts []string
+	err := &SwitchTypesError{Impossible: impossibleTyps}
 
-	if len(impossibleTyps) > 0 {
-		errParts = append(errParts, fmt.Sprintf("impossible types %v", impossibleTyps))
+	if !hasDefaultCase {
+		err.Missing = missingTyps
 	}
 
-	if !hasDefaultCase && len(missingTyps) > 0 {
-		errParts = append(errParts, fmt.Sprintf("missing types %v", missingTyps))
-	}
-
-	if len(errParts) == 0 {
+	if len(err.Impossible) == 0 && len(err.Missing) == 0 {
 		return nil
 	}
 
-	return fmt.Errorf(strings.Join(errParts, ", "))
+	return err
+}
+
+// withoutOverlapping returns the types of impossible that are not
+// annotated interface types whose allowed types overlap names, among typs,
+// as for type assertions.
+func (ch *OneOfChecker) withoutOverlapping(impossible []string, typs []types.Type, names []string) []string {
+	if ch.Allowed == nil || ch.Overlap == nil {
+		return impossible
+	}
+	var kept []string
+	for _, name := range impossible {
+		overlapping := false
+		for _, typ := range typs {
+			if typ == nil || typ.String() != name {
+				continue
+			}
+			if allowed, ok := ch.Allowed(typ); ok && ch.Overlap(allowed, names) {
+				overlapping = true
+				break
+			}
+		}
+		if !overlapping {
+			kept = append(kept, name)
+		}
+	}
+	return kept
 }
 
 func (ch *OneOfChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
@@ -470,13 +654,21 @@ func (ch *OneOfChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) erro
 		return nil
 	}
 
+	if ch.Allowed != nil {
+		if allowed, ok := ch.Allowed(rhs); ok {
+			extraTypes := typesNotIn(allowed, spec.OneOf)
+			if ch.Implementing != nil {
+				extraTypes = typesNotIn(extraTypes, ch.Implementing(extraTypes, spec.OneOf))
+			}
+			return subsumeError(lhs, rhs, extraTypes, "allowed", spec.OneOf)
+		}
+	}
+
 	_, impossibleTypes := checkPossibleTypes(spec.OneOf, []types.Type{rhs})
 
-	if len(impossibleTypes) > 0 {
-		annTypeStr := types.TypeString(lhs, func(*types.Package) string { return "" })
-		dynTypeStr := types.TypeString(rhs, func(*types.Package) string { return "" })
-		return fmt.Errorf("%s cannot contain dynamic type %s, allowed types: %+v",
-			annTypeStr, dynTypeStr, strings.Join(spec.OneOf, ", "))
+	if len(impossibleTypes) > 0 && !implementsAny(ch.Implements, rhs, spec.OneOf) {
+		return fmt.Errorf("%s cannot contain dynamic type %s, allowed types: %s",
+			typeString(lhs), typeString(rhs), strings.Join(typeNameStrings(spec.OneOf), ", "))
 	}
 
 	return nil
@@ -489,21 +681,42 @@ func (ch *NoneOfChecker) CheckSwitchTypes(spec *Constraints, lhs types.Type, swi
 
 	impossibleTyps := checkImpossibleTypes(spec.NoneOf, switchTypes)
 
+	err := &SwitchTypesError{
+		Impossible:        impossibleTyps,
+		DefaultNotAllowed: hasDefaultCase,
+	}
+
+	if len(err.Impossible) == 0 && !err.DefaultNotAllowed {
+		return nil
+	}
+
+	return err
+}
+
+// SwitchTypesError is returned for type switches with missing or impossible
+// cases.
+type SwitchTypesError struct {
+	Missing           []string
+	Impossible        []string
+	DefaultNotAllowed bool
+}
+
+func (err *SwitchTypesError) Error() string {
 	var errParts []string
 
-	if len(impossibleTyps) > 0 {
-		errParts = append(errParts, fmt.Sprintf("impossible types %v", impossibleTyps))
+	if len(err.Impossible) > 0 {
+		errParts = append(errParts, fmt.Sprintf("impossible types [%s]", strings.Join(typeNameStrings(err.Impossible), " ")))
 	}
 
-	if hasDefaultCase {
-		errParts = append(errParts, fmt.Sprintf("default case not allowed"))
+	if len(err.Missing) > 0 {
+		errParts = append(errParts, fmt.Sprintf("missing types [%s]", strings.Join(typeNameStrings(err.Missing), " ")))
 	}
 
-	if len(errParts) == 0 {
-		return nil
+	if err.DefaultNotAllowed {
+		errParts = append(errParts, "default case not allowed")
 	}
 
-	return fmt.Errorf(strings.Join(errParts, ", "))
+	return strings.Join(errParts, ", ")
 }
 
 func (ch *NoneOfChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) error {
@@ -514,18 +727,62 @@ func (ch *NoneOfChecker) CheckAssign(spec *Constraints, lhs, rhs types.Type) err
 		return nil
 	}
 
+	if ch.Allowed != nil {
+		if allowed, ok := ch.Allowed(rhs); ok {
+			forbiddenTypes := typesIn(allowed, spec.NoneOf)
+			if ch.Implementing != nil {
+				forbiddenTypes = append(forbiddenTypes, ch.Implementing(typesNotIn(allowed, spec.NoneOf), spec.NoneOf)...)
+			}
+			return subsumeError(lhs, rhs, forbiddenTypes, "forbidden", spec.NoneOf)
+		}
+	}
+
 	impossibleTypes := checkImpossibleTypes(spec.NoneOf, []types.Type{rhs})
 
-	if len(impossibleTypes) > 0 {
-		annTypeStr := types.TypeString(lhs, func(*types.Package) string { return "" })
-		dynTypeStr := types.TypeString(rhs, func(*types.Package) string { return "" })
-		return fmt.Errorf("%s cannot contain dynamic type %s, forbidden types: %+v",
-			annTypeStr, dynTypeStr, strings.Join(spec.NoneOf, ", "))
+	if len(impossibleTypes) > 0 || implementsAny(ch.Implements, rhs, spec.NoneOf) {
+		return fmt.Errorf("%s cannot contain dynamic type %s, forbidden types: %s",
+			typeString(lhs), typeString(rhs), strings.Join(typeNameStrings(spec.NoneOf), ", "))
 	}
 
 	return nil
 }
 
+// subsumeError returns an error if the annotated interface type rhs can
+// contain types that lhs cannot, e.g.
+//
+//	YY cannot contain dynamic type string of XX, allowed types: int
+func subsumeError(lhs, rhs types.Type, extraTypes []string, kind string, specTypes []string) error {
+	if len(extraTypes) == 0 {
+		return nil
+	}
+	noun := "type"
+	if len(extraTypes) > 1 {
+		noun = "types"
+	}
+	return fmt.Errorf("%s cannot contain dynamic %s %s of %s, %s types: %s",
+		typeString(lhs), noun, strings.Join(typeNameStrings(extraTypes), ", "), typeString(rhs), kind, strings.Join(typeNameStrings(specTypes), ", "))
+}
+
+// typeString returns typ as written in messages, without package paths.
+func typeString(typ types.Type) string {
+	return types.TypeString(typ, func(*types.Package) string { return "" })
+}
+
+// pkgPathRe matches the package paths in type names, e.g.
+// "github.com/siadat/intertype/testfiles." in
+// "*github.com/siadat/intertype/testfiles.Deleted".
+var pkgPathRe = regexp.MustCompile(`(?:[\w.-]+/)*[\w-]+\.`)
+
+// typeNameStrings returns the type names of annotations as written in
+// messages, without package paths, like typeString.
+func typeNameStrings(names []string) []string {
+	short := make([]string, len(names))
+	for i, name := range names {
+		short[i] = pkgPathRe.ReplaceAllString(name, "")
+	}
+	return short
+}
+
 func parseIntertypeCommentLines(anns []string) (*Constraints, error) {
 	lines := make([]string, 0, len(anns))
 	for i := range anns {
@@ -550,3 +807,37 @@ func parseIntertypeCommentLines(anns []string) (*Constraints, error) {
 
 	return &spec, nil
 }
+
+// parseIntertypeDirective parses a single directive line of the form
+//
+//	// #intertype [target...] {constraints}
+//	//intertype:target [args...] {constraints}
+//
+// and returns the target words (e.g. ["param", "val"]) and the constraints.
+// It returns a nil constraint if line is not a directive.
+func parseIntertypeDirective(line string) ([]string, *Constraints, error) {
+	switch {
+	case isSuppression(line):
+		return nil, nil, nil
+	case strings.HasPrefix(line, "// #intertype "):
+		line = strings.TrimPrefix(line, "// #intertype ")
+	case strings.HasPrefix(line, "//intertype:"):
+		line = strings.TrimPrefix(line, "//intertype:")
+	default:
+		return nil, nil, nil
+	}
+
+	idx := strings.Index(line, "{")
+	if idx == -1 {
+		return nil, nil, fmt.Errorf("missing constraints in %q", line)
+	}
+
+	target := strings.Fields(line[:idx])
+	var spec Constraints
+	err := yaml.Unmarshal([]byte(line[idx:]), &spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %v %q", err, line[idx:])
+	}
+
+	return target, &spec, nil
+}
@@ -0,0 +1,307 @@
+package intertype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddFuncDirectives registers the directives found in the doc comment of a
+// function declaration:
+//
+//	// #intertype param val {IsPointer: true}
+//	// #intertype result 0 {OneOf: [int, float64]}
+//	// #intertype {SameTypes: [[Params, 0], [Params, 1]]}
+//	func Store(key, val interface{}) interface{}
+//
+// They are translated into the "[Params, i] pkg.Store", "[Returns, i] pkg.Store"
+// and "[] pkg.Store" matchers respectively.
+func (an *Analyzer) AddFuncDirectives(fn *types.Func, doc *ast.CommentGroup) {
+	if fn == nil || doc == nil {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+
+	for _, comment := range doc.List {
+		target, constraint, ok := an.parseDirective(comment)
+		if !ok {
+			continue
+		}
+
+		var matcher string
+
+		switch {
+		case len(target) == 0:
+			matcher = fmt.Sprintf("[] %s", fn.FullName())
+		case len(target) == 2 && target[0] == "param":
+			idx, err := tupleIndex(sig.Params(), target[1])
+			if err != nil {
+				an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: %v", err)
+				continue
+			}
+			matcher = fmt.Sprintf("[Params, %d] %s", idx, fn.FullName())
+		case len(target) == 2 && target[0] == "result":
+			idx, err := tupleIndex(sig.Results(), target[1])
+			if err != nil {
+				an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: %v", err)
+				continue
+			}
+			matcher = fmt.Sprintf("[Returns, %d] %s", idx, fn.FullName())
+		default:
+			an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: unsupported target %q for function %s", target, fn.Name())
+			continue
+		}
+
+		an.AddMatcher(matcher, *constraint, comment.Pos())
+	}
+}
+
+// AddTypeDirectives registers the directives found in the doc or line comment
+// of a type declaration. Interface types, including declarations like
+// "type Key any", and Enum types accept directives without a target, map
+// types accept "key" and "elem" targets and slice and array types accept
+// the "elem" target, as do channel types:
+//
+//	// #intertype {OneOf: [string, int]}
+//	type Key interface{}
+//
+//	// #intertype {Enum: true}
+//	type Color int
+//
+//	// #intertype key {OneOf: [string]}
+//	// #intertype elem {IsPointer: true}
+//	type Registry map[interface{}]interface{}
+//
+//	// #intertype elem {OneOf: [string, int]}
+//	type Keys []interface{}
+//
+// For alias declarations typ is the aliased type, which must be a named type.
+func (an *Analyzer) AddTypeDirectives(typ types.Type, comments ...*ast.CommentGroup) {
+	for _, cg := range comments {
+		if cg == nil {
+			continue
+		}
+		for _, comment := range cg.List {
+			target, constraint, ok := an.parseDirective(comment)
+			if !ok {
+				continue
+			}
+
+			if _, isNamed := typ.(*types.Named); !isNamed {
+				an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: cannot annotate an alias of unnamed type %s", typ)
+				continue
+			}
+
+			_, isMap := typ.Underlying().(*types.Map)
+			_, isInterface := typ.Underlying().(*types.Interface)
+			_, isBasic := typ.Underlying().(*types.Basic)
+
+			var matcher string
+
+			switch {
+			case len(target) == 0 && isInterface:
+				matcher = fmt.Sprintf("[] %s", typ)
+			case len(target) == 0 && isBasic && constraint.Enum:
+				matcher = fmt.Sprintf("[] %s", typ)
+				constraint.EnumMembers = enumMembers(typ.(*types.Named))
+			case len(target) == 1 && target[0] == "key" && isMap:
+				matcher = fmt.Sprintf("[Key] %s", typ)
+			case len(target) == 1 && target[0] == "elem" && hasElem(typ):
+				matcher = fmt.Sprintf("[Elem] %s", typ)
+			default:
+				an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: unsupported target %q for type %s", target, typ)
+				continue
+			}
+
+			an.AddMatcher(matcher, *constraint, comment.Pos())
+		}
+	}
+}
+
+// enumMembers returns the constants of type typ declared in the same scope
+// as typ, in declaration order.
+func enumMembers(typ *types.Named) []EnumMember {
+	scope := typ.Obj().Parent()
+	if scope == nil {
+		return nil
+	}
+
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), typ) {
+			consts = append(consts, c)
+		}
+	}
+
+	sort.Slice(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+
+	members := make([]EnumMember, len(consts))
+	for i, c := range consts {
+		members[i] = EnumMember{Name: c.Name(), Value: c.Val().ExactString()}
+	}
+	return members
+}
+
+// enumCases returns the members of the Enum type typ that a switch in the
+// analyzed package can have cases for, i.e. its exported members if typ
+// is declared in another package. The members are the ones collected from
+// the comment annotation of typ, or its constants for annotations in
+// intertype.yaml, which does not list them.
+func (an *Analyzer) enumCases(typ types.Type, members []EnumMember) []EnumMember {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return members
+	}
+	if members == nil {
+		members = enumMembers(named)
+	}
+	if named.Obj().Pkg() == an.AnalysisPass.Pkg {
+		return members
+	}
+
+	var exported []EnumMember
+	for _, member := range members {
+		if token.IsExported(member.Name) {
+			exported = append(exported, member)
+		}
+	}
+	return exported
+}
+
+// AddFieldDirectives registers the directives found in the doc or line
+// comments of the fields of a struct type declaration:
+//
+//	type Envelope struct {
+//		// #intertype {OneOf: [string, int]}
+//		ID      interface{}
+//		Payload interface{} // #intertype {IsPointer: true}
+//	}
+//
+// They are translated into the "[] (pkg.Envelope).ID" and
+// "[] (pkg.Envelope).Payload" matchers.
+func (an *Analyzer) AddFieldDirectives(owner types.Type, structNode *ast.StructType) {
+	for _, field := range structNode.Fields.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(field.Names) == 0 {
+			// embedded field
+			typ := derefType(an.AnalysisPass.TypesInfo.TypeOf(field.Type))
+			if named, ok := typ.(*types.Named); ok {
+				names = append(names, named.Obj().Name())
+			}
+		}
+
+		for _, cg := range []*ast.CommentGroup{field.Doc, field.Comment} {
+			if cg == nil {
+				continue
+			}
+			for _, comment := range cg.List {
+				target, constraint, ok := an.parseDirective(comment)
+				if !ok {
+					continue
+				}
+				if len(target) != 0 {
+					an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: unsupported target %q for field", target)
+					continue
+				}
+
+				for _, name := range names {
+					an.AddMatcher(fmt.Sprintf("[] (%s).%s", owner, name), *constraint, comment.Pos())
+				}
+			}
+		}
+	}
+}
+
+// AddAlias makes the annotations of target apply to the alias declaration
+// alias as well. Depending on the Go version, the type of an expression
+// declared with an alias type is either the target type or the alias
+// itself, so both need to match.
+func (an *Analyzer) AddAlias(alias, target types.Type) {
+	aliasName, targetName := alias.String(), target.String()
+	if aliasName == targetName {
+		return
+	}
+
+	for matcher, items := range an.Annots {
+		for _, prefix := range []string{"[] ", "[Key] ", "[Elem] ", "[] ("} {
+			if prefix == "[] (" {
+				if strings.HasPrefix(matcher, prefix+targetName+").") {
+					aliasMatcher := prefix + aliasName + strings.TrimPrefix(matcher, prefix+targetName)
+					an.addItems(aliasMatcher, items...)
+				}
+				continue
+			}
+			if matcher == prefix+targetName {
+				an.addItems(prefix+aliasName, items...)
+			}
+		}
+	}
+}
+
+// parseDirective parses a directive comment and resolves the type names in
+// its constraints relative to the position of the comment. Invalid
+// directives are reported and ok is false.
+func (an *Analyzer) parseDirective(comment *ast.Comment) (target []string, constraint *Constraints, ok bool) {
+	target, constraint, err := parseIntertypeDirective(comment.Text)
+	if err != nil {
+		an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: %v", err)
+		return nil, nil, false
+	}
+	if constraint == nil {
+		return nil, nil, false
+	}
+	if err := constraint.Report.Validate(); err != nil {
+		an.AnalysisPass.Reportf(comment.Pos(), "invalid annotation: %v", err)
+		return nil, nil, false
+	}
+
+	an.resolveTypeNames(comment.Pos(), constraint)
+	return target, constraint, true
+}
+
+// resolveTypeNames qualifies the type names in OneOf and NoneOf as they
+// would be resolved at pos, so that "Created" or "*Deleted" written in a
+// comment match the dynamic types "example.com/pkg.Created" and
+// "*example.com/pkg.Deleted". Names that are not types are kept as is.
+func (an *Analyzer) resolveTypeNames(pos token.Pos, constraint *Constraints) {
+	resolve := func(names []string) {
+		for i := range names {
+			tv, err := types.Eval(an.AnalysisPass.Fset, an.AnalysisPass.Pkg, pos, names[i])
+			if err != nil || !tv.IsType() {
+				continue
+			}
+			names[i] = tv.Type.String()
+		}
+	}
+
+	resolve(constraint.OneOf)
+	resolve(constraint.NoneOf)
+}
+
+// tupleIndex resolves a parameter or result given by name or by index.
+func tupleIndex(tuple *types.Tuple, nameOrIndex string) (int, error) {
+	if idx, err := strconv.Atoi(nameOrIndex); err == nil {
+		if idx < 0 || idx >= tuple.Len() {
+			return 0, fmt.Errorf("index %d out of range", idx)
+		}
+		return idx, nil
+	}
+
+	for i := 0; i < tuple.Len(); i++ {
+		if tuple.At(i).Name() == nameOrIndex {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no parameter or result named %q", nameOrIndex)
+}
@@ -0,0 +1,191 @@
+package intertype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// explainer records how the nodes at a source position are checked, for
+// the -explain flag and the explain command:
+//
+//	ExtIndexExpr visits *ast.IndexExpr at testfiles/test1.go:62:6
+//	  matcher "[] github.com/siadat/intertype/testfiles.XX": 1 annotation(s)
+//	    from testfiles/test1.go:17:2: {"OneOf":["int","float64","string"]}
+//	    check XX <- bool
+//	      OneOfChecker: XX cannot contain dynamic type bool, allowed types: int, float64, string
+//	      ok: IsPointer, IsInterface, ...
+//	  report at testfiles/test1.go:62:12: XX cannot contain dynamic type bool, allowed types: int, float64, string
+type explainer struct {
+	target token.Pos
+	line   int
+
+	// lines are the events of the pass being run, which are printed if
+	// there are any.
+	active   bool
+	lines    []string
+	verdicts []verdict
+	out      strings.Builder
+}
+
+type verdict struct {
+	checker string
+	err     error
+}
+
+// newExplainer returns an explainer for the position "file:line:column"
+// if it is in a file of the analyzed package, or nil.
+func (an *Analyzer) newExplainer(position string) (*explainer, error) {
+	parts := strings.Split(position, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid position %q, want file:line:column", position)
+	}
+	line, err1 := strconv.Atoi(parts[1])
+	column, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || line < 1 || column < 1 {
+		return nil, fmt.Errorf("invalid position %q, want file:line:column", position)
+	}
+	filename, err := filepath.Abs(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	fset := an.AnalysisPass.Fset
+	for _, f := range an.AnalysisPass.Files {
+		file := fset.File(f.Pos())
+		if file.Name() != filename || line > file.LineCount() {
+			continue
+		}
+		return &explainer{
+			target: file.LineStart(line) + token.Pos(column-1),
+			line:   line,
+		}, nil
+	}
+	return nil, nil
+}
+
+// explainPass runs the pass p on n, and records what it checks if n is
+// at the explained position, i.e. it encloses it and starts at its line.
+func (an *Analyzer) explainPass(p Passer, n ast.Node, run func()) {
+	ex := an.explain
+	if ex == nil || an.summarizing || n == nil || n.Pos() > ex.target || ex.target > n.End() ||
+		an.AnalysisPass.Fset.Position(n.Pos()).Line != ex.line {
+		run()
+		return
+	}
+
+	ex.active, ex.lines = true, nil
+	run()
+	ex.active = false
+
+	if len(ex.lines) == 0 {
+		return
+	}
+	fmt.Fprintf(&ex.out, "%s visits %T at %s\n", typeName(p), n, an.position(n.Pos()))
+	for _, line := range ex.lines {
+		fmt.Fprintf(&ex.out, "  %s\n", line)
+	}
+}
+
+// explainf records an event of the pass being explained, if any.
+func (an *Analyzer) explainf(format string, args ...interface{}) {
+	if an.explain == nil || !an.explain.active {
+		return
+	}
+	an.explain.lines = append(an.explain.lines, fmt.Sprintf(format, args...))
+}
+
+// explainMatcher records the annotations found for matcher.
+func (an *Analyzer) explainMatcher(matcher string) {
+	if an.explain == nil || !an.explain.active {
+		return
+	}
+	items := an.Annots[matcher]
+	if len(items) == 0 {
+		an.explainf("matcher %q: no annotations", matcher)
+		return
+	}
+	an.explainf("matcher %q: %d annotation(s)", matcher, len(items))
+	for _, item := range items {
+		from := "unknown source"
+		if item.Source.IsValid() {
+			from = an.relPosition(item.Source)
+		}
+		if len(item.Via) > 0 {
+			from += " via " + hopsString(item.Via)
+		}
+		an.explainf("  from %s: %s", from, strings.TrimSpace(constraintString(item.Check)))
+	}
+}
+
+// explainVerdict records the verdict of the checker ch, which is recorded
+// with the checked types by explainChecked.
+func (an *Analyzer) explainVerdict(ch interface{}, err error) {
+	if an.explain == nil || !an.explain.active {
+		return
+	}
+	an.explain.verdicts = append(an.explain.verdicts, verdict{checker: typeName(ch), err: err})
+}
+
+// explainChecked records the verdicts of the checkers of lhs and rhs:
+//
+//	check XX <- bool
+//	  OneOfChecker: XX cannot contain dynamic type bool, allowed types: int, float64, string
+//	  ok: IsPointer, IsInterface, ...
+func (an *Analyzer) explainChecked(lhs, rhs string) {
+	if an.explain == nil || !an.explain.active {
+		return
+	}
+	an.explainf("  check %s <- %s", lhs, rhs)
+	var ok []string
+	for _, v := range an.explain.verdicts {
+		if v.err == nil {
+			ok = append(ok, v.checker)
+			continue
+		}
+		an.explainf("    %s: %v", v.checker, v.err)
+	}
+	if len(ok) > 0 {
+		an.explainf("    ok: %s", strings.Join(ok, ", "))
+	}
+	an.explain.verdicts = nil
+}
+
+// PrintExplanation prints what was recorded for the explained position.
+func (an *Analyzer) PrintExplanation() {
+	if an.explain == nil {
+		return
+	}
+	if an.explain.out.Len() == 0 {
+		fmt.Fprintf(os.Stdout, "no passes check %s\n", an.position(an.explain.target))
+		return
+	}
+	fmt.Fprint(os.Stdout, an.explain.out.String())
+}
+
+func (an *Analyzer) position(pos token.Pos) string {
+	return an.relPosition(an.AnalysisPass.Fset.Position(pos))
+}
+
+func (an *Analyzer) relPosition(position token.Position) string {
+	if rel, ok := workDirRel(position.Filename); ok && isInWorkDir(position.Filename) {
+		position.Filename = rel
+	}
+	return position.String()
+}
+
+// typeName returns the name of the type of v without its package, e.g.
+// "OneOfChecker".
+func typeName(v interface{}) string {
+	name := fmt.Sprintf("%T", v)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func typeNameList(typs []types.Type) string {
+	return "[" + typeNames(typs) + "]"
+}
@@ -0,0 +1,39 @@
+package intertype
+
+import (
+	"fmt"
+)
+
+// annotationsFact carries the annotations declared in the comments of a
+// package, and the ones inferred from it, to the packages that import it.
+type annotationsFact struct {
+	Annots map[string][]YamlAnnotItem
+}
+
+func (*annotationsFact) AFact() {}
+
+func (f *annotationsFact) String() string {
+	return fmt.Sprintf("annotations(%d)", len(f.Annots))
+}
+
+// ImportFacts adds the annotations exported by the dependencies of the
+// analyzed package.
+func (an *Analyzer) ImportFacts() {
+	for _, fact := range an.AnalysisPass.AllPackageFacts() {
+		annots, ok := fact.Fact.(*annotationsFact)
+		if !ok || fact.Package == an.AnalysisPass.Pkg {
+			continue
+		}
+		for matcher, items := range annots.Annots {
+			an.Annots[matcher] = append(an.Annots[matcher], items...)
+		}
+	}
+}
+
+// ExportFacts exports the annotations declared in the analyzed package.
+func (an *Analyzer) ExportFacts() {
+	if len(an.Exports) == 0 {
+		return
+	}
+	an.AnalysisPass.ExportPackageFact(&annotationsFact{Annots: an.Exports})
+}
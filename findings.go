@@ -0,0 +1,93 @@
+package intertype
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+)
+
+// Result is the result of MyAnalyzer, for drivers that output the
+// violations in a structured form.
+type Result struct {
+	Findings []Finding
+
+	// Diagnostics are the diagnostics reported by the analyzer, in the
+	// order they are reported, with the findings grouped in each.
+	Diagnostics []Diagnostic
+
+	// Observations are recorded with -infer.
+	Observations []Observation
+}
+
+// Finding is a violation of an annotation reported by the analyzer.
+type Finding struct {
+	Pos      token.Pos
+	Message  string
+	Severity string // error, warning or info
+	Category string
+	DocURL   string
+
+	// Matcher and Constraint are the annotation that is violated, and
+	// Source is where it is declared. They are empty for the violations
+	// that are not of an annotation.
+	Matcher    string
+	Constraint string
+	Source     token.Position
+
+	AnnotatedType string
+	DynamicType   string
+}
+
+// Diagnostic is a diagnostic reported by the analyzer, with its severity
+// and the findings grouped in it, which analysis.Diagnostic does not
+// carry. Drivers match them by Pos and Category, in the order they are
+// reported.
+type Diagnostic struct {
+	Pos      token.Pos
+	Category string
+	Severity string
+	Findings []Finding
+}
+
+// addFinding records the finding of err at pos and returns it.
+func (an *Analyzer) addFinding(pos token.Pos, err error) Finding {
+	msg := err.Error()
+	for _, finding := range an.findings {
+		if finding.Pos == pos && finding.Message == msg {
+			return finding
+		}
+	}
+
+	finding := Finding{
+		Pos:      pos,
+		Message:  msg,
+		Severity: severity(err),
+		Category: category(err),
+	}
+
+	var annotErr *annotationError
+	if errors.As(err, &annotErr) {
+		item := annotErr.item
+		if !annotErr.own {
+			finding.DocURL = item.Check.Report.DocURL
+		}
+		finding.Matcher = item.Matcher
+		finding.Constraint = constraintString(item.Check)
+		finding.Source = item.Source
+		finding.AnnotatedType = annotErr.annotated
+		finding.DynamicType = annotErr.dynamic
+	}
+
+	an.findings = append(an.findings, finding)
+	return finding
+}
+
+// constraintString returns the constraints of spec on one line, e.g.
+// {"OneOf":["string"]}.
+func constraintString(spec Constraints) string {
+	byts, err := json.Marshal(spec)
+	if err != nil {
+		return spec.String()
+	}
+	return string(byts)
+}
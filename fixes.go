@@ -0,0 +1,374 @@
+package intertype
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// nodeError attaches the node a violation was found at to the error of a
+// checker, for suggesting fixes.
+type nodeError struct {
+	err  error
+	node ast.Node
+}
+
+func (err *nodeError) Error() string {
+	return err.err.Error()
+}
+
+func (err *nodeError) Unwrap() error {
+	return err.err
+}
+
+// suggestedFixes returns the fixes for the mechanical cases of err:
+// missing and impossible cases of type switches, values that should be
+// pointers, and missing struct tags.
+func (an *Analyzer) suggestedFixes(err error) []analysis.SuggestedFix {
+	var fixes []analysis.SuggestedFix
+
+	var nodeErr *nodeError
+	errors.As(err, &nodeErr)
+
+	var switchErr *SwitchTypesError
+	if errors.As(err, &switchErr) && nodeErr != nil {
+		if sw, ok := nodeErr.node.(*ast.TypeSwitchStmt); ok {
+			fixes = append(fixes, an.switchFixes(sw, switchErr)...)
+		}
+	}
+
+	var pointerErr *NotPointerError
+	if errors.As(err, &pointerErr) && nodeErr != nil {
+		// the value is not a pointer itself, rather than one of the types
+		// of its annotated interface type
+		if expr, ok := nodeErr.node.(ast.Expr); ok && types.Identical(an.AnalysisPass.TypesInfo.TypeOf(expr), pointerErr.Got) &&
+			isAddressable(an.AnalysisPass.TypesInfo, expr) {
+			fixes = append(fixes, analysis.SuggestedFix{
+				Message: "Pass a pointer",
+				TextEdits: []analysis.TextEdit{
+					{Pos: expr.Pos(), End: expr.Pos(), NewText: []byte("&")},
+				},
+			})
+		}
+	}
+
+	var tagsErr *MissingTagsError
+	if errors.As(err, &tagsErr) {
+		if fix, ok := an.tagsFix(tagsErr); ok {
+			fixes = append(fixes, fix)
+		}
+	}
+
+	return fixes
+}
+
+// switchFixes returns a fix removing the impossible cases of sw and adding
+// the missing ones, as one fix so that both are applied together. The fix
+// is a single edit rewriting the body of the switch statement, with the
+// clauses printed from the syntax tree, as edits that touch each other,
+// e.g. a removal of the last clause and an insertion before the brace, are
+// not all applied by the checker.
+func (an *Analyzer) switchFixes(sw *ast.TypeSwitchStmt, err *SwitchTypesError) []analysis.SuggestedFix {
+	pass := an.AnalysisPass
+	file := an.fileOf(sw.Pos())
+	if file == nil || sw.Body == nil {
+		return nil
+	}
+
+	impossible := make(map[string]bool)
+	for _, typ := range err.Impossible {
+		impossible[typ] = true
+	}
+
+	depth := indentOf(file, sw)
+	indent := strings.Repeat("\t", depth)
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8, Indent: depth}
+
+	var text bytes.Buffer
+	var messages []string
+	text.WriteString("{\n")
+
+	// writeComments writes the comments of file between pos and end, which
+	// are not in a clause node, e.g. after the last statement of a clause
+	writeComments := func(pos, end token.Pos, indent string) {
+		for _, cg := range file.Comments {
+			if pos <= cg.Pos() && cg.End() <= end {
+				for _, c := range cg.List {
+					text.WriteString(indent + c.Text + "\n")
+				}
+			}
+		}
+	}
+	if len(sw.Body.List) > 0 {
+		writeComments(sw.Body.Lbrace+1, sw.Body.List[0].Pos(), indent)
+	}
+
+	for i, stmt := range sw.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		end := sw.Body.Rbrace
+		if i+1 < len(sw.Body.List) {
+			end = sw.Body.List[i+1].Pos()
+		}
+
+		var kept []ast.Expr
+		for _, expr := range clause.List {
+			if typ := pass.TypesInfo.TypeOf(expr); typ == nil || !impossible[typ.String()] {
+				kept = append(kept, expr)
+			}
+		}
+		if len(kept) < len(clause.List) && len(messages) == 0 {
+			messages = append(messages, "remove impossible cases")
+		}
+		if len(kept) == 0 && len(clause.List) > 0 {
+			// the clause is removed with its comments
+			continue
+		}
+
+		// the syntax tree is shared with other analyzers, so the clause
+		// is copied rather than changed
+		node := &ast.CaseClause{Case: clause.Case, List: kept, Colon: clause.Colon, Body: clause.Body}
+		var comments []*ast.CommentGroup
+		for _, cg := range file.Comments {
+			if clause.Pos() <= cg.Pos() && cg.End() <= clause.End() {
+				comments = append(comments, cg)
+			}
+		}
+		if err := cfg.Fprint(&text, pass.Fset, &printer.CommentedNode{Node: node, Comments: comments}); err != nil {
+			return nil
+		}
+		text.WriteString("\n")
+		writeComments(clause.End(), end, indent+"\t")
+	}
+
+	if len(err.Missing) > 0 {
+		var cases []string
+		for _, typ := range err.Missing {
+			name, ok := an.typeNameInFile(file, typ)
+			if !ok {
+				cases = nil
+				break
+			}
+			cases = append(cases, indent+"case "+name+":\n")
+		}
+
+		if len(cases) > 0 {
+			text.WriteString(strings.Join(cases, ""))
+			messages = append(messages, "add missing cases")
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	text.WriteString(indent + "}")
+
+	message := strings.Join(messages, " and ")
+	return []analysis.SuggestedFix{{
+		Message: strings.ToUpper(message[:1]) + message[1:],
+		TextEdits: []analysis.TextEdit{
+			{Pos: sw.Body.Lbrace, End: sw.Body.Rbrace + 1, NewText: text.Bytes()},
+		},
+	}}
+}
+
+// indentOf returns the indentation of the statement stmt of file, as
+// gofmt prints it.
+func indentOf(file *ast.File, stmt ast.Stmt) int {
+	path, _ := astutil.PathEnclosingInterval(file, stmt.Pos(), stmt.End())
+	indent := 0
+	for i := 1; i < len(path); i++ {
+		switch node := path[i].(type) {
+		case *ast.BlockStmt:
+			// the clauses of switch and select statements are not indented
+			switch path[i+1].(type) {
+			case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			default:
+				indent++
+			}
+		case *ast.CaseClause, *ast.CommClause:
+			indent++
+		case *ast.CompositeLit:
+			if node.Lbrace.IsValid() && path[i-1] != ast.Node(node.Type) {
+				indent++
+			}
+		}
+	}
+	return indent
+}
+
+func (an *Analyzer) tagsFix(err *MissingTagsError) (analysis.SuggestedFix, bool) {
+	named, ok := derefType(err.Typ).(*types.Named)
+	if !ok || named.Obj().Pkg() != an.AnalysisPass.Pkg {
+		return analysis.SuggestedFix{}, false
+	}
+
+	structNode := an.structDecl(named.Obj())
+	if structNode == nil {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var edits []analysis.TextEdit
+	for _, field := range structNode.Fields.List {
+		if len(field.Names) != 1 {
+			continue
+		}
+		name := field.Names[0].Name
+		missing := err.Missing[name]
+		if len(missing) == 0 {
+			continue
+		}
+
+		var tags []string
+		for _, tag := range missing {
+			tags = append(tags, fmt.Sprintf("%s:%q", tag, lowerFirst(name)))
+		}
+
+		if field.Tag == nil {
+			edits = append(edits, analysis.TextEdit{
+				Pos:     field.Type.End(),
+				End:     field.Type.End(),
+				NewText: []byte(fmt.Sprintf(" `%s`", strings.Join(tags, " "))),
+			})
+			continue
+		}
+
+		value, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		value = strings.TrimSpace(value + " " + strings.Join(tags, " "))
+		if strings.Contains(value, "`") {
+			continue
+		}
+		edits = append(edits, analysis.TextEdit{
+			Pos:     field.Tag.Pos(),
+			End:     field.Tag.End(),
+			NewText: []byte(fmt.Sprintf("`%s`", value)),
+		})
+	}
+
+	if len(edits) == 0 {
+		return analysis.SuggestedFix{}, false
+	}
+
+	return analysis.SuggestedFix{
+		Message:   "Add missing struct tags",
+		TextEdits: edits,
+	}, true
+}
+
+// isAddressable reports whether &expr is valid.
+func isAddressable(typesInfo *types.Info, expr ast.Expr) bool {
+	switch expr := unparen(expr).(type) {
+	case *ast.Ident:
+		_, ok := typesInfo.Uses[expr].(*types.Var)
+		return ok
+	case *ast.CompositeLit:
+		return true
+	case *ast.SelectorExpr:
+		sel, ok := typesInfo.Selections[expr]
+		if !ok || sel.Kind() != types.FieldVal {
+			return false
+		}
+		if _, isPtr := typesInfo.TypeOf(expr.X).Underlying().(*types.Pointer); isPtr {
+			return true
+		}
+		return isAddressable(typesInfo, expr.X)
+	case *ast.IndexExpr:
+		switch typ := typesInfo.TypeOf(expr.X).Underlying().(type) {
+		case *types.Slice:
+			return true
+		case *types.Array:
+			return isAddressable(typesInfo, expr.X)
+		case *types.Pointer:
+			_, isArray := typ.Elem().Underlying().(*types.Array)
+			return isArray
+		}
+	}
+	return false
+}
+
+var qualifiedNameRegexp = regexp.MustCompile(`((?:[\w.-]+/)*[\w.-]+)\.([A-Za-z_]\w*)`)
+
+// typeNameInFile returns the type name typ, as in the messages of the
+// checkers, written as it would be in file, e.g. "context.Context" or
+// "Created" for a type of the analyzed package. It returns false if file
+// does not import the package of the type.
+func (an *Analyzer) typeNameInFile(file *ast.File, typ string) (string, bool) {
+	if typ == "untyped nil" {
+		return "nil", true
+	}
+
+	ok := true
+	name := qualifiedNameRegexp.ReplaceAllStringFunc(typ, func(qualified string) string {
+		parts := qualifiedNameRegexp.FindStringSubmatch(qualified)
+		path, name := parts[1], parts[2]
+		if path == an.AnalysisPass.Pkg.Path() {
+			return name
+		}
+		for _, imp := range file.Imports {
+			impPath, _ := strconv.Unquote(imp.Path.Value)
+			if impPath != path {
+				continue
+			}
+			if imp.Name != nil {
+				return imp.Name.Name + "." + name
+			}
+			if pkgName, isPkgName := an.AnalysisPass.TypesInfo.Implicits[imp].(*types.PkgName); isPkgName {
+				return pkgName.Name() + "." + name
+			}
+		}
+		ok = false
+		return qualified
+	})
+	return name, ok
+}
+
+func (an *Analyzer) fileOf(pos token.Pos) *ast.File {
+	fset := an.AnalysisPass.Fset
+	for _, f := range an.AnalysisPass.Files {
+		if fset.File(f.Pos()) == fset.File(pos) {
+			return f
+		}
+	}
+	return nil
+}
+
+// structDecl returns the struct type declared as obj, or nil.
+func (an *Analyzer) structDecl(obj types.Object) *ast.StructType {
+	file := an.fileOf(obj.Pos())
+	if file == nil {
+		return nil
+	}
+
+	var structNode *ast.StructType
+	ast.Inspect(file, func(n ast.Node) bool {
+		typeSpec, ok := n.(*ast.TypeSpec)
+		if !ok || typeSpec.Name.Pos() != obj.Pos() {
+			return structNode == nil
+		}
+		structNode, _ = typeSpec.Type.(*ast.StructType)
+		return false
+	})
+	return structNode
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,249 @@
+package intertype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+type funcEscape struct {
+	pos token.Pos
+	fn  *types.Func
+}
+
+// TrackFuncValues finds the local variables that only ever hold one
+// function, e.g. f in
+//
+//	f := context.WithValue
+//	f(ctx, 1, 2)
+//
+// or var f = context.WithValue, so that calls through them are checked
+// like calls to the function itself. Other uses of functions as values,
+// including uses of tracked variables other than calls, e.g. passing f to
+// another function, and functions assigned to variables that are
+// reassigned to other values, are recorded, and reported by
+// ReportFuncEscapes if the function is annotated.
+func (an *Analyzer) TrackFuncValues() {
+	pass := an.AnalysisPass
+	assigned := make(map[*types.Var][]funcEscape)
+	unknown := make(map[*types.Var]bool)
+	uses := make(map[*types.Var][]token.Pos)
+
+	assign := func(lhs ast.Expr, rhs ast.Expr) {
+		ident, ok := unparen(lhs).(*ast.Ident)
+		if !ok {
+			return
+		}
+		v, ok := pass.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok || v.Parent() == nil || v.Parent() == pass.Pkg.Scope() {
+			return
+		}
+		fn := funcRef(pass.TypesInfo, rhs)
+		if fn == nil {
+			unknown[v] = true
+			return
+		}
+		if len(assigned[v]) > 0 && assigned[v][0].fn != fn {
+			unknown[v] = true
+		}
+		assigned[v] = append(assigned[v], funcEscape{pos: rhs.Pos(), fn: fn})
+	}
+
+	for _, f := range pass.Files {
+		var stack []ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			defer func() { stack = append(stack, n) }()
+
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					break
+				}
+				for i := range n.Lhs {
+					assign(n.Lhs[i], n.Rhs[i])
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) != len(n.Values) {
+					break
+				}
+				for i := range n.Names {
+					assign(n.Names[i], n.Values[i])
+				}
+			case *ast.UnaryExpr:
+				if n.Op != token.AND {
+					break
+				}
+				if ident, ok := unparen(n.X).(*ast.Ident); ok {
+					if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok {
+						unknown[v] = true
+					}
+				}
+			case *ast.Ident, *ast.SelectorExpr:
+				if len(stack) > 0 {
+					if sel, ok := stack[len(stack)-1].(*ast.SelectorExpr); ok && sel.Sel == n {
+						// handled with the selector
+						break
+					}
+				}
+				if ident, ok := n.(*ast.Ident); ok {
+					if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok && !isCalledOrAssigned(stack, ident) {
+						uses[v] = append(uses[v], ident.Pos())
+					}
+				}
+				fn := funcRef(pass.TypesInfo, n.(ast.Expr))
+				if fn == nil || isFuncValueTracked(pass.TypesInfo, stack, n.(ast.Expr)) {
+					break
+				}
+				an.funcEscapes = append(an.funcEscapes, funcEscape{pos: n.Pos(), fn: fn})
+			}
+			return true
+		})
+	}
+
+	an.funcValues = make(map[*types.Var]*types.Func)
+	for v, fns := range assigned {
+		if unknown[v] {
+			// the calls through v are not checked at all
+			an.funcEscapes = append(an.funcEscapes, fns...)
+			continue
+		}
+		an.funcValues[v] = fns[0].fn
+		for _, pos := range uses[v] {
+			an.funcEscapes = append(an.funcEscapes, funcEscape{pos: pos, fn: fns[0].fn})
+		}
+	}
+	sort.Slice(an.funcEscapes, func(i, j int) bool {
+		return an.funcEscapes[i].pos < an.funcEscapes[j].pos
+	})
+}
+
+// isCalledOrAssigned reports whether ident, the innermost node of stack,
+// is called, or assigned to.
+func isCalledOrAssigned(stack []ast.Node, ident *ast.Ident) bool {
+	var parent ast.Node
+	for i := len(stack) - 1; i >= 0; i-- {
+		if _, ok := stack[i].(*ast.ParenExpr); !ok {
+			parent = stack[i]
+			break
+		}
+	}
+
+	switch parent := parent.(type) {
+	case *ast.CallExpr:
+		return unparen(parent.Fun) == ident
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if unparen(lhs) == ident {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isFuncValueTracked reports whether the function referred to by expr
+// is called directly, or assigned to a local variable.
+func isFuncValueTracked(typesInfo *types.Info, stack []ast.Node, expr ast.Expr) bool {
+	var parent ast.Node
+	for i := len(stack) - 1; i >= 0; i-- {
+		if _, ok := stack[i].(*ast.ParenExpr); !ok {
+			parent = stack[i]
+			break
+		}
+	}
+
+	isLocal := func(ident *ast.Ident) bool {
+		v, ok := typesInfo.ObjectOf(ident).(*types.Var)
+		return ok && v.Parent() != nil && v.Pkg() != nil && v.Parent() != v.Pkg().Scope()
+	}
+
+	switch parent := parent.(type) {
+	case *ast.CallExpr:
+		return unparen(parent.Fun) == expr
+	case *ast.AssignStmt:
+		for i := range parent.Rhs {
+			if unparen(parent.Rhs[i]) != expr || len(parent.Lhs) != len(parent.Rhs) {
+				continue
+			}
+			ident, ok := unparen(parent.Lhs[i]).(*ast.Ident)
+			return ok && isLocal(ident)
+		}
+	case *ast.ValueSpec:
+		for i := range parent.Values {
+			if unparen(parent.Values[i]) == expr && len(parent.Names) == len(parent.Values) {
+				return isLocal(parent.Names[i])
+			}
+		}
+	}
+	return false
+}
+
+// ReportFuncEscapes warns about annotated functions used as values other
+// than in calls and assignments to local variables, as the calls through
+// them cannot be checked.
+func (an *Analyzer) ReportFuncEscapes() {
+	for _, escape := range an.funcEscapes {
+		if !an.hasFuncAnnots(escape.fn) {
+			continue
+		}
+		an.logError(an.AnalysisPass.Fset, escape.pos, &categoryError{
+			err:      fmt.Errorf("annotated function %s escapes as a value, calls through it are not checked", escape.fn.FullName()),
+			category: "func-escape",
+			severity: "warning",
+		})
+	}
+}
+
+// funcValueCallee returns the function called through a tracked local
+// variable, or nil.
+func (an *Analyzer) funcValueCallee(call *ast.CallExpr) *types.Func {
+	ident, ok := unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := an.AnalysisPass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return nil
+	}
+	return an.funcValues[v]
+}
+
+// hasFuncAnnots reports whether the arguments of calls to fn are checked.
+func (an *Analyzer) hasFuncAnnots(fn *types.Func) bool {
+	name := fn.FullName()
+	for matcher := range an.Annots {
+		if matcher == fmt.Sprintf("[] %s", name) {
+			return true
+		}
+		if strings.HasPrefix(matcher, "[Params, ") && strings.HasSuffix(matcher, "] "+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// funcRef returns the function expr refers to, for package functions and
+// method values like c.Value, or nil.
+func funcRef(typesInfo *types.Info, expr ast.Expr) *types.Func {
+	switch expr := unparen(expr).(type) {
+	case *ast.Ident:
+		fn, _ := typesInfo.Uses[expr].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		if sel, ok := typesInfo.Selections[expr]; ok && sel.Kind() != types.MethodVal {
+			// method expressions like (*T).M take the receiver as their
+			// first parameter
+			return nil
+		}
+		fn, _ := typesInfo.Uses[expr.Sel].(*types.Func)
+		return fn
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package intertype
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var methodMatcherRegexp = regexp.MustCompile(`^\[(Params|Returns), (\d+)\] \(([^*()]+)\)\.(\w+)$`)
+
+// InheritMethodAnnotations makes the annotations of interface methods, like
+//
+//	"[Params, 0] (context.Context).Value"
+//
+// apply to the methods of the types of the analyzed package that implement
+// the interface, e.g. "[Params, 0] (*pkg.myCtx).Value", so that direct
+// calls to them and the returns in their bodies are checked too.
+func (an *Analyzer) InheritMethodAnnotations() {
+	pkgs := importedPackages(an.AnalysisPass.Pkg)
+	scope := an.AnalysisPass.Pkg.Scope()
+
+	// deterministic order, as the derived annotations are exported
+	matchers := make([]string, 0, len(an.Annots))
+	for matcher := range an.Annots {
+		matchers = append(matchers, matcher)
+	}
+	sort.Strings(matchers)
+
+	for _, matcher := range matchers {
+		parts := methodMatcherRegexp.FindStringSubmatch(matcher)
+		if parts == nil {
+			continue
+		}
+		kind, idx, ifaceName, methodName := parts[1], parts[2], parts[3], parts[4]
+
+		iface := lookupInterface(pkgs, ifaceName)
+		if iface == nil {
+			continue
+		}
+
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() || types.IsInterface(typeName.Type()) {
+				continue
+			}
+
+			var recv types.Type = typeName.Type()
+			if !types.Implements(recv, iface) {
+				recv = types.NewPointer(recv)
+				if !types.Implements(recv, iface) {
+					continue
+				}
+			}
+
+			obj, _, _ := types.LookupFieldOrMethod(recv, true, an.AnalysisPass.Pkg, methodName)
+			method, ok := obj.(*types.Func)
+			if !ok || method.Pkg() != an.AnalysisPass.Pkg {
+				// promoted from a type declared elsewhere
+				continue
+			}
+
+			an.addItems(fmt.Sprintf("[%s, %s] %s", kind, idx, method.FullName()), an.Annots[matcher]...)
+		}
+	}
+}
+
+// lookupInterface returns the interface type with the qualified name
+// "path/to/pkg.Name" among pkgs, or nil.
+func lookupInterface(pkgs map[string]*types.Package, qualifiedName string) *types.Interface {
+	dot := strings.LastIndex(qualifiedName, ".")
+	if dot < 0 {
+		return nil
+	}
+	pkg, ok := pkgs[qualifiedName[:dot]]
+	if !ok {
+		return nil
+	}
+	typeName, ok := pkg.Scope().Lookup(qualifiedName[dot+1:]).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	iface, _ := typeName.Type().Underlying().(*types.Interface)
+	return iface
+}
+
+// importedPackages returns pkg and the packages it imports, directly or
+// indirectly, by path.
+func importedPackages(pkg *types.Package) map[string]*types.Package {
+	pkgs := make(map[string]*types.Package)
+	var add func(p *types.Package)
+	add = func(p *types.Package) {
+		if _, ok := pkgs[p.Path()]; ok {
+			return
+		}
+		pkgs[p.Path()] = p
+		for _, imp := range p.Imports() {
+			add(imp)
+		}
+	}
+	add(pkg)
+	return pkgs
+}
+
+// ImplementsNamed reports whether typ implements the interface type named
+// by name in OneOf or NoneOf, e.g. "error" or "io.Reader", so that the
+// entries naming interfaces match the types implementing them.
+func (an *Analyzer) ImplementsNamed(typ types.Type, name string) bool {
+	if typ == nil || isUntypedNil(typ) {
+		return false
+	}
+	named := an.LookupType(name)
+	if named == nil {
+		return false
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	return ok && types.Implements(typ, iface)
+}
+
+// LookupType returns the type named by name in OneOf or NoneOf, e.g.
+// "error", "*int" or "path/to/pkg.Name", among the packages imported by
+// the analyzed package, or nil.
+func (an *Analyzer) LookupType(name string) types.Type {
+	if strings.HasPrefix(name, "*") {
+		elem := an.LookupType(name[1:])
+		if elem == nil {
+			return nil
+		}
+		return types.NewPointer(elem)
+	}
+	if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+		return obj.Type()
+	}
+
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return nil
+	}
+	if an.pkgs == nil {
+		an.pkgs = importedPackages(an.AnalysisPass.Pkg)
+	}
+	pkg, ok := an.pkgs[name[:dot]]
+	if !ok {
+		return nil
+	}
+	typeName, ok := pkg.Scope().Lookup(name[dot+1:]).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return typeName.Type()
+}
+
+// namesImplementing returns the types named in names that implement one
+// of the interface types named in ifaceNames.
+func (an *Analyzer) namesImplementing(names, ifaceNames []string) []string {
+	var implementing []string
+	for _, name := range names {
+		if typ := an.LookupType(name); typ != nil && implementsAny(an.ImplementsNamed, typ, ifaceNames) {
+			implementing = append(implementing, name)
+		}
+	}
+	return implementing
+}
+
+// namesOverlap reports whether a value can have one of the types named in
+// a and in b, i.e. whether a type is in both, or implements an interface
+// type named in the other, or both name interface types.
+func (an *Analyzer) namesOverlap(a, b []string) bool {
+	if len(typesIn(a, b)) > 0 || len(an.namesImplementing(a, b)) > 0 || len(an.namesImplementing(b, a)) > 0 {
+		return true
+	}
+	return an.hasInterfaceName(a) && an.hasInterfaceName(b)
+}
+
+// hasInterfaceName reports whether one of the types named in names is an
+// interface type.
+func (an *Analyzer) hasInterfaceName(names []string) bool {
+	for _, name := range names {
+		if typ := an.LookupType(name); typ != nil && types.IsInterface(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUntypedNil(typ types.Type) bool {
+	basic, ok := typ.(*types.Basic)
+	return ok && basic.Kind() == types.UntypedNil
+}
@@ -0,0 +1,224 @@
+package intertype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Observation is a type seen in an empty interface{} slot without
+// annotations, with -infer: the type of a value assigned, passed or
+// returned to it, or a type it is switched on or asserted to.
+type Observation struct {
+	Matcher string
+	Pos     token.Position
+
+	// Type is the dynamic type, and Kind its kind constraint, e.g.
+	// "IsPointer", if it has one. Both are empty if the source is an
+	// interface value whose dynamic type is unknown.
+	Type string
+	Kind string
+}
+
+// observe records the dynamic type typ of the value of rhs flowing into
+// the slot of matcher, whose type is lhsType. rhs may be nil.
+func (an *Analyzer) observe(matcher string, lhsType, typ types.Type, rhs ast.Expr) {
+	if !*inferMode || an.summarizing || lhsType == nil || typ == nil || !isEmptyInterface(lhsType) {
+		return
+	}
+	_, named := lhsType.(*types.Named)
+	if named != (matcher == fmt.Sprintf("[] %s", lhsType)) {
+		// a local variable, not a slot that can be annotated, or a slot of
+		// a named type, whose own "[] T" is proposed instead
+		return
+	}
+	if _, annotated := an.Annots[matcher]; annotated {
+		return
+	}
+	if isUntypedNil(typ) {
+		return
+	}
+
+	var position token.Position
+	if rhs != nil {
+		position = an.AnalysisPass.Fset.Position(rhs.Pos())
+		if conv := an.conversionArg(rhs); conv != nil {
+			typ = an.AnalysisPass.TypesInfo.TypeOf(conv)
+		}
+	}
+	if !types.IsInterface(typ) {
+		an.addObservation(Observation{Matcher: matcher, Pos: position, Type: typ.String(), Kind: kindOf(typ)})
+		return
+	}
+
+	// the types an annotated interface can contain are known
+	for _, item := range an.Annots[fmt.Sprintf("[] %s", typ)] {
+		if len(item.Check.OneOf) == 0 {
+			continue
+		}
+		for _, name := range item.Check.OneOf {
+			an.addObservation(Observation{Matcher: matcher, Pos: position, Type: name})
+		}
+		return
+	}
+	an.addObservation(Observation{Matcher: matcher, Pos: position})
+}
+
+// observeAssert records the types that expr is switched on or asserted
+// to, if it is a parameter of an enclosing function, or of a named empty
+// interface type.
+func (an *Analyzer) observeAssert(expr ast.Expr, typs []types.Type, pos token.Pos) {
+	if !*inferMode || an.summarizing || expr == nil {
+		return
+	}
+	typesInfo := an.AnalysisPass.TypesInfo
+	lhsType := typesInfo.TypeOf(expr)
+	if lhsType == nil || !isEmptyInterface(lhsType) {
+		return
+	}
+	position := an.AnalysisPass.Fset.Position(pos)
+
+	var matchers []string
+	if _, named := lhsType.(*types.Named); named {
+		// rather than the parameter
+		matchers = append(matchers, fmt.Sprintf("[] %s", lhsType))
+	} else if ident, ok := unparen(expr).(*ast.Ident); ok {
+		if param, ok := typesInfo.Uses[ident].(*types.Var); ok {
+			if ref, ok := an.params[param]; ok {
+				matchers = append(matchers, fmt.Sprintf("[Params, %d] %s", ref.idx, ref.fn.FullName()))
+			}
+		}
+	}
+
+	for _, matcher := range matchers {
+		if _, annotated := an.Annots[matcher]; annotated {
+			continue
+		}
+		for _, typ := range typs {
+			if typ != nil && !types.IsInterface(typ) && !isUntypedNil(typ) {
+				an.addObservation(Observation{Matcher: matcher, Pos: position, Type: typ.String(), Kind: kindOf(typ)})
+			}
+		}
+	}
+}
+
+// conversionArg returns x if expr is a conversion T(x) to an interface
+// type, or nil.
+func (an *Analyzer) conversionArg(expr ast.Expr) ast.Expr {
+	typesInfo := an.AnalysisPass.TypesInfo
+	call, ok := unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || !typesInfo.Types[call.Fun].IsType() || !types.IsInterface(typesInfo.TypeOf(call.Fun)) {
+		return nil
+	}
+	return call.Args[0]
+}
+
+func (an *Analyzer) addObservation(obs Observation) {
+	for _, o := range an.observations {
+		if o == obs {
+			return
+		}
+	}
+	an.observations = append(an.observations, obs)
+}
+
+// kindOf returns the kind constraint satisfied by the values of typ, or
+// "" if there is none.
+func kindOf(typ types.Type) string {
+	switch typ.Underlying().(type) {
+	case *types.Pointer:
+		return "IsPointer"
+	case *types.Slice:
+		return "IsSlice"
+	case *types.Map:
+		return "IsMap"
+	case *types.Struct:
+		return "IsStruct"
+	case *types.Signature:
+		return "IsFunc"
+	case *types.Chan:
+		return "IsChan"
+	}
+	return ""
+}
+
+// Proposal is an annotation proposed for a slot from the types observed
+// in it: Kind, e.g. "IsPointer", if there are many types of the same kind,
+// or OneOf the types otherwise.
+type Proposal struct {
+	Slot  Slot
+	Kind  string
+	OneOf []string
+
+	// Observed is the number of observations, and Unconstrained are the
+	// positions of the ones whose dynamic type is unknown, which makes
+	// the proposal less certain.
+	Observed      int
+	Unconstrained []token.Position
+}
+
+// maxOneOf is the number of types above which a kind constraint is
+// proposed instead of OneOf, if they have the same kind.
+const maxOneOf = 3
+
+// Propose returns a proposal for each of the slots that have
+// observations, in the order of slots. The observations of other slots,
+// e.g. declared in packages that are not analyzed, are ignored.
+func Propose(slots []Slot, observations []Observation) []Proposal {
+	byMatcher := make(map[string][]Observation)
+	for _, obs := range observations {
+		byMatcher[obs.Matcher] = append(byMatcher[obs.Matcher], obs)
+	}
+
+	var proposals []Proposal
+	for _, slot := range slots {
+		observed := byMatcher[slot.Matcher]
+		if len(observed) == 0 {
+			continue
+		}
+		delete(byMatcher, slot.Matcher)
+
+		proposal := Proposal{Slot: slot, Observed: len(observed)}
+		kinds := make(map[string]bool)
+		seen := make(map[string]bool)
+		for _, obs := range observed {
+			if obs.Type == "" {
+				proposal.Unconstrained = append(proposal.Unconstrained, obs.Pos)
+				continue
+			}
+			kinds[obs.Kind] = true
+			if !seen[obs.Type] {
+				seen[obs.Type] = true
+				proposal.OneOf = append(proposal.OneOf, obs.Type)
+			}
+		}
+		sort.Strings(proposal.OneOf)
+
+		if len(kinds) == 1 && len(proposal.OneOf) > maxOneOf {
+			for kind := range kinds {
+				proposal.Kind = kind
+			}
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals
+}
+
+// String returns the constraint proposed as in intertype.yaml, e.g.
+// {"OneOf": ["int", "string"]}.
+func (p Proposal) String() string {
+	if p.Kind != "" {
+		return fmt.Sprintf("{%q: true}", p.Kind)
+	}
+	if len(p.OneOf) == 0 {
+		return "{}"
+	}
+	quoted := make([]string, len(p.OneOf))
+	for i, typ := range p.OneOf {
+		quoted[i] = fmt.Sprintf("%q", typ)
+	}
+	return fmt.Sprintf("{\"OneOf\": [%s]}", strings.Join(quoted, ", "))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/siadat/intertype"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// report is a diagnostic of a root package, with its severity and the
+// findings grouped in it.
+type report struct {
+	diag     analysis.Diagnostic
+	severity string
+	findings []intertype.Finding
+}
+
+// driverOutput is what the analysis of the packages matching the patterns
+// given to runDriver produces.
+type driverOutput struct {
+	fset    *token.FileSet
+	roots   []*packages.Package
+	reports []report
+	results []*intertype.Result
+}
+
+// runDriver analyzes the packages matching patterns, and their
+// dependencies for the annotations they export, and returns the
+// diagnostics and results of the packages matching patterns.
+func runDriver(patterns []string) (*driverOutput, error) {
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax}
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no packages matching %v", patterns)
+	}
+
+	isRoot := make(map[*packages.Package]bool)
+	var rootPaths []string
+	for _, pkg := range roots {
+		isRoot[pkg] = true
+		rootPaths = append(rootPaths, pkg.PkgPath)
+	}
+	intertype.SetRootPackages(rootPaths)
+
+	facts := make(map[*types.Package][]analysis.Fact)
+	out := &driverOutput{fset: roots[0].Fset, roots: roots}
+	var runErr error
+
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		if runErr != nil || pkg.Types == nil || pkg.TypesInfo == nil {
+			return
+		}
+		if isRoot[pkg] {
+			for _, err := range pkg.Errors {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+
+		var diags []analysis.Diagnostic
+		result, err := analyze(pkg, intertype.MyAnalyzer, facts, func(diag analysis.Diagnostic) {
+			diags = append(diags, diag)
+		})
+		if err != nil {
+			runErr = fmt.Errorf("%s: %v", pkg.PkgPath, err)
+			return
+		}
+		if !isRoot[pkg] {
+			return
+		}
+		out.results = append(out.results, result.(*intertype.Result))
+
+		reported := result.(*intertype.Result).Diagnostics
+		for _, diag := range diags {
+			r := report{diag: diag, severity: "error"}
+			// the diagnostics that are not violations, e.g. invalid
+			// annotations, are not in the result
+			for i := range reported {
+				if reported[i].Pos == diag.Pos && reported[i].Category == diag.Category {
+					r.severity = reported[i].Severity
+					r.findings = reported[i].Findings
+					reported = append(reported[:i:i], reported[i+1:]...)
+					break
+				}
+			}
+			out.reports = append(out.reports, r)
+		}
+	})
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	reports := out.reports
+	sort.SliceStable(reports, func(i, j int) bool {
+		a, b := out.fset.Position(reports[i].diag.Pos), out.fset.Position(reports[j].diag.Pos)
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return out, nil
+}
+
+// analyze runs a and the analyzers it requires on pkg. Only package facts
+// are supported, as they are the only ones intertype uses.
+func analyze(pkg *packages.Package, a *analysis.Analyzer, facts map[*types.Package][]analysis.Fact, report func(analysis.Diagnostic)) (interface{}, error) {
+	resultOf := make(map[*analysis.Analyzer]interface{})
+	for _, req := range a.Requires {
+		result, err := analyze(pkg, req, facts, func(analysis.Diagnostic) {})
+		if err != nil {
+			return nil, err
+		}
+		resultOf[req] = result
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       pkg.Fset,
+		Files:      pkg.Syntax,
+		OtherFiles: pkg.OtherFiles,
+		Pkg:        pkg.Types,
+		TypesInfo:  pkg.TypesInfo,
+		TypesSizes: pkg.TypesSizes,
+		Report:     report,
+		ResultOf:   resultOf,
+
+		ImportObjectFact: func(types.Object, analysis.Fact) bool { return false },
+		ExportObjectFact: func(types.Object, analysis.Fact) {},
+		AllObjectFacts:   func() []analysis.ObjectFact { return nil },
+
+		ImportPackageFact: func(p *types.Package, fact analysis.Fact) bool {
+			for _, f := range facts[p] {
+				if reflect.TypeOf(f) == reflect.TypeOf(fact) {
+					reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+					return true
+				}
+			}
+			return false
+		},
+		ExportPackageFact: func(fact analysis.Fact) {
+			facts[pkg.Types] = append(facts[pkg.Types], fact)
+		},
+		AllPackageFacts: func() []analysis.PackageFact {
+			deps := dependencies(pkg)
+			var all []analysis.PackageFact
+			for p, pkgFacts := range facts {
+				if !deps[p] {
+					continue
+				}
+				for _, fact := range pkgFacts {
+					all = append(all, analysis.PackageFact{Package: p, Fact: fact})
+				}
+			}
+			sort.Slice(all, func(i, j int) bool {
+				return all[i].Package.Path() < all[j].Package.Path()
+			})
+			return all
+		},
+	}
+	if len(a.FactTypes) == 0 {
+		pass.ExportPackageFact = func(analysis.Fact) {}
+	}
+
+	return a.Run(pass)
+}
+
+// dependencies returns pkg and the packages it imports, directly or
+// indirectly.
+func dependencies(pkg *packages.Package) map[*types.Package]bool {
+	deps := make(map[*types.Package]bool)
+	packages.Visit([]*packages.Package{pkg}, func(p *packages.Package) bool {
+		if deps[p.Types] {
+			return false
+		}
+		deps[p.Types] = true
+		return true
+	}, nil)
+	return deps
+}
+
+// relPath returns filename relative to the working directory if it is in
+// it, as paths are written in the reports.
+func relPath(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil || rel == ".." || len(rel) > 2 && rel[:3] == ".."+string(filepath.Separator) {
+		return filepath.ToSlash(filename)
+	}
+	return filepath.ToSlash(rel)
+}
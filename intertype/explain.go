@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/siadat/intertype"
+)
+
+// runExplain prints how the node at a position is checked: the passes
+// that visit it, the matchers they build, the annotations found for them
+// and the verdicts of the checkers.
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	intertype.MyAnalyzer.Flags.VisitAll(func(f *flag.Flag) {
+		if f.Name != "explain" {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: intertype explain [flags] file.go:line:column\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || strings.Count(fs.Arg(0), ":") != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	position := fs.Arg(0)
+	filename := position[:strings.Index(position, ":")]
+	if _, err := os.Stat(filename); err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		return 1
+	}
+
+	intertype.MyAnalyzer.Flags.Set("explain", position)
+	// the output of the analyzer is the explanation, the reports are in it
+	if _, err := runDriver([]string{"file=" + filename}); err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/siadat/intertype"
+)
+
+// runInfer prints an intertype.yaml proposing annotations for the empty
+// interface{} slots declared in the packages, from the types observed in
+// them.
+func runInfer(args []string) int {
+	fs := flag.NewFlagSet("infer", flag.ExitOnError)
+	unexported := fs.Bool("unexported", false, "include unexported functions, types, methods and fields")
+	intertype.MyAnalyzer.Flags.VisitAll(func(f *flag.Flag) {
+		switch f.Name {
+		case "infer", "explain", "baseline", "write-baseline", "prune-baseline":
+		default:
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: intertype infer [-unexported] [flags] packages...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	intertype.MyAnalyzer.Flags.Set("infer", "true")
+	out, err := runDriver(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		return 1
+	}
+
+	var slots []intertype.Slot
+	for _, pkg := range out.roots {
+		slots = append(slots, intertype.ListSlots(pkg.Types, *unexported)...)
+	}
+	var observations []intertype.Observation
+	for _, result := range out.results {
+		observations = append(observations, result.Observations...)
+	}
+
+	for _, proposal := range intertype.Propose(slots, observations) {
+		slot := proposal.Slot
+		fmt.Printf("# %s\n", slot.Kind)
+		fmt.Printf("# %q\n", slot.Matcher+" "+slot.Type)
+		fmt.Printf("# observed %d time(s)\n", proposal.Observed)
+		if len(proposal.Unconstrained) > 0 {
+			var positions []string
+			for _, position := range proposal.Unconstrained {
+				if position.IsValid() {
+					positions = append(positions, fmt.Sprintf("%s:%d:%d", relPath(position.Filename), position.Line, position.Column))
+				}
+			}
+			fmt.Printf("# low confidence: values of unknown dynamic type are used")
+			if len(positions) > 0 {
+				fmt.Printf(" at %s", strings.Join(positions, ", "))
+			}
+			fmt.Printf("\n")
+		}
+		fmt.Printf("%q:\n", slot.Matcher)
+		fmt.Printf("  - check: %s\n\n", proposal)
+	}
+	return 0
+}
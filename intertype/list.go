@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/siadat/intertype"
+	"golang.org/x/tools/go/packages"
+)
+
+// runList prints the matchers of the empty interface{} slots declared in
+// the packages, or a skeleton of intertype.yaml with -yaml.
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	yamlOut := fs.Bool("yaml", false, "print a skeleton intertype.yaml with an annotation for every slot")
+	unexported := fs.Bool("unexported", false, "include unexported functions, types, methods and fields")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: intertype list [-yaml] [-unexported] packages...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo}
+	pkgs, err := packages.Load(cfg, fs.Args()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		return 1
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return 1
+	}
+
+	for _, pkg := range pkgs {
+		for _, slot := range intertype.ListSlots(pkg.Types, *unexported) {
+			if !*yamlOut {
+				fmt.Printf("%s\t%s\n", slot.Matcher, slot.Type)
+				continue
+			}
+			fmt.Printf("# %s\n", slot.Kind)
+			fmt.Printf("# %q\n", slot.Matcher+" "+slot.Type)
+			fmt.Printf("%q:\n", slot.Matcher)
+			fmt.Printf("  - check: {}\n\n")
+		}
+	}
+	return 0
+}
@@ -1,10 +1,122 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/siadat/intertype"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
 func main() {
-	singlechecker.Main(intertype.MyAnalyzer)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "list":
+			os.Exit(runList(os.Args[2:]))
+		case "explain":
+			os.Exit(runExplain(os.Args[2:]))
+		case "infer":
+			os.Exit(runInfer(os.Args[2:]))
+		}
+	}
+
+	if usesChecker(os.Args[1:]) {
+		singlechecker.Main(intertype.MyAnalyzer)
+		return
+	}
+
+	fs := flag.NewFlagSet("intertype", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	intertype.MyAnalyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Parse(os.Args[1:])
+
+	switch *format {
+	case "text", "json", "sarif":
+	default:
+		fmt.Fprintf(os.Stderr, "intertype: invalid -format %q, want text, json or sarif\n", *format)
+		os.Exit(2)
+	}
+
+	out, err := runDriver(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stderr, out.fset, out.reports)
+	case "json":
+		err = writeJSON(os.Stdout, out.fset, out.reports)
+	case "sarif":
+		err = writeSARIF(os.Stdout, out.fset, out.reports)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "intertype: %v\n", err)
+		os.Exit(1)
+	}
+	for _, r := range out.reports {
+		if r.severity == "error" {
+			// warnings and infos do not fail the command
+			os.Exit(3)
+		}
+	}
+}
+
+// checkerFlags are the flags of singlechecker that this command does not
+// know, e.g. -fix, which applies the suggested fixes.
+var checkerFlags = map[string]bool{
+	"fix":        true,
+	"json":       true,
+	"c":          true,
+	"test":       true,
+	"flags":      true,
+	"debug":      true,
+	"cpuprofile": true,
+	"memprofile": true,
+	"trace":      true,
+	"V":          true,
+}
+
+// usesChecker reports whether the arguments are for singlechecker rather
+// than for the driver of this command: with a flag of singlechecker,
+// without packages, to print the usage, or when run by go vet with a .cfg
+// file.
+func usesChecker(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			rest := args[i:]
+			if arg == "--" {
+				rest = args[i+1:]
+			}
+			return len(rest) == 0 || len(rest) == 1 && strings.HasSuffix(rest[0], ".cfg")
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if checkerFlags[strings.SplitN(name, "=", 2)[0]] {
+			return true
+		}
+		if !strings.Contains(name, "=") && !isBoolFlag(name) {
+			// -format json
+			i++
+		}
+	}
+	return true
+}
+
+func isBoolFlag(name string) bool {
+	if name == "format" {
+		return false
+	}
+	f := intertype.MyAnalyzer.Flags.Lookup(name)
+	if f == nil {
+		return true
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
 }
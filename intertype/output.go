@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// writeText writes the diagnostics as singlechecker does, with their
+// severity unless they are errors, followed by their related information,
+// indented, at its own position, e.g. where the annotation that is
+// violated is declared.
+func writeText(w io.Writer, fset *token.FileSet, reports []report) error {
+	for _, r := range reports {
+		msg := r.diag.Message
+		if sev := r.severity; sev != "error" {
+			msg = sev + ": " + msg
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", fset.Position(r.diag.Pos), msg); err != nil {
+			return err
+		}
+		for _, rel := range r.diag.Related {
+			if _, err := fmt.Fprintf(w, "%s: \t%s\n", fset.Position(rel.Pos), rel.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonVersion is the version of the schema of the -format=json output.
+// It is incremented when fields are removed or change meaning.
+const jsonVersion = 1
+
+type jsonOutput struct {
+	Version     int              `json:"version"`
+	Diagnostics []jsonDiagnostic `json:"diagnostics"`
+}
+
+type jsonDiagnostic struct {
+	jsonPosition
+	Message       string        `json:"message"`
+	Severity      string        `json:"severity"`
+	Category      string        `json:"category,omitempty"`
+	DocURL        string        `json:"docURL,omitempty"`
+	Matcher       string        `json:"matcher,omitempty"`
+	Constraint    string        `json:"constraint,omitempty"`
+	AnnotatedType string        `json:"annotatedType,omitempty"`
+	DynamicType   string        `json:"dynamicType,omitempty"`
+	Source        *jsonPosition `json:"source,omitempty"`
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func newJSONPosition(position token.Position) jsonPosition {
+	return jsonPosition{File: relPath(position.Filename), Line: position.Line, Column: position.Column}
+}
+
+// jsonDiagnostics returns one diagnostic for each finding of the reports,
+// as the findings of one position are grouped in one report, and one for
+// each report without findings, e.g. for invalid annotations.
+func jsonDiagnostics(fset *token.FileSet, reports []report) []jsonDiagnostic {
+	diags := []jsonDiagnostic{}
+	for _, r := range reports {
+		position := newJSONPosition(fset.Position(r.diag.Pos))
+
+		if len(r.findings) == 0 {
+			diags = append(diags, jsonDiagnostic{
+				jsonPosition: position,
+				Message:      r.diag.Message,
+				Severity:     r.severity,
+				Category:     r.diag.Category,
+			})
+			continue
+		}
+
+		for _, finding := range r.findings {
+			diag := jsonDiagnostic{
+				jsonPosition:  position,
+				Message:       finding.Message,
+				Severity:      finding.Severity,
+				Category:      finding.Category,
+				DocURL:        finding.DocURL,
+				Matcher:       finding.Matcher,
+				Constraint:    finding.Constraint,
+				AnnotatedType: finding.AnnotatedType,
+				DynamicType:   finding.DynamicType,
+			}
+			if finding.Source.IsValid() {
+				source := newJSONPosition(finding.Source)
+				diag.Source = &source
+			}
+			diags = append(diags, diag)
+		}
+	}
+	return diags
+}
+
+func writeJSON(w io.Writer, fset *token.FileSet, reports []report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonOutput{
+		Version:     jsonVersion,
+		Diagnostics: jsonDiagnostics(fset, reports),
+	})
+}
+
+// SARIF 2.1.0, as read by code scanning dashboards.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID           string            `json:"ruleId"`
+	Level            string            `json:"level"`
+	Message          sarifMessage      `json:"message"`
+	Locations        []sarifLocation   `json:"locations"`
+	RelatedLocations []sarifLocation   `json:"relatedLocations,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// newSarifLocation returns the location of position, relative to
+// %SRCROOT%, the working directory, or an absolute file URI for the files
+// outside of it.
+func newSarifLocation(position jsonPosition) sarifLocation {
+	artifact := sarifArtifactLocation{URI: position.File, URIBaseID: "%SRCROOT%"}
+	if path.IsAbs(position.File) {
+		artifact = sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: position.File}).String()}
+	} else {
+		artifact.URI = (&url.URL{Path: position.File}).String()
+	}
+	return sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: artifact,
+			Region:           sarifRegion{StartLine: position.Line, StartColumn: position.Column},
+		},
+	}
+}
+
+// sarifRuleID returns the rule ID of the violations of matcher, which has
+// no category, e.g. "params-0/pkg.Put" for "[Params, 0] pkg.Put", as rule
+// IDs have no spaces or brackets.
+func sarifRuleID(matcher string) string {
+	i := strings.Index(matcher, "] ")
+	if !strings.HasPrefix(matcher, "[") || i < 0 {
+		return "intertype"
+	}
+	address := strings.FieldsFunc(matcher[1:i], func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	name := matcher[i+2:]
+	if len(address) == 0 {
+		return name
+	}
+	return strings.ToLower(strings.Join(address, "-")) + "/" + name
+}
+
+// ruleDescriptions are the descriptions of the categories of the
+// diagnostics of intertype itself, which are not violations of annotations.
+var ruleDescriptions = map[string]string{
+	"unverified":         "value whose dynamic type is not verified against an annotated slot",
+	"func-escape":        "annotated function used as a value, whose calls are not checked",
+	"unused-suppression": "ignore directive that does not ignore any violation",
+}
+
+// sarifLevels maps the severities to the levels of SARIF.
+var sarifLevels = map[string]string{
+	"error":   "error",
+	"warning": "warning",
+	"info":    "note",
+}
+
+func writeSARIF(w io.Writer, fset *token.FileSet, reports []report) error {
+	driver := sarifDriver{
+		Name:           "intertype",
+		InformationURI: "https://github.com/siadat/intertype",
+		Rules:          []sarifRule{},
+	}
+	rules := make(map[string]bool)
+	results := []sarifResult{}
+
+	for _, diag := range jsonDiagnostics(fset, reports) {
+		// rules are the categories of the annotations, or their matchers
+		ruleID := diag.Category
+		if ruleID == "" {
+			ruleID = sarifRuleID(diag.Matcher)
+		}
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			description, own := ruleDescriptions[ruleID]
+			switch {
+			case own:
+			case diag.Matcher == "":
+				description = "intertype diagnostic"
+			default:
+				description = "violation of the annotation " + diag.Matcher
+			}
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: description},
+				HelpURI:          diag.DocURL,
+			})
+		}
+
+		result := sarifResult{
+			RuleID:    ruleID,
+			Level:     sarifLevels[diag.Severity],
+			Message:   sarifMessage{Text: diag.Message},
+			Locations: []sarifLocation{newSarifLocation(diag.jsonPosition)},
+		}
+		if diag.Source != nil {
+			related := newSarifLocation(*diag.Source)
+			id := 0
+			related.ID = &id
+			related.Message = &sarifMessage{Text: "annotation " + diag.Matcher}
+			result.RelatedLocations = []sarifLocation{related}
+		}
+		if diag.Matcher != "" {
+			result.Properties = map[string]string{
+				"matcher":       diag.Matcher,
+				"constraint":    diag.Constraint,
+				"annotatedType": diag.AnnotatedType,
+				"dynamicType":   diag.DynamicType,
+			}
+		}
+		results = append(results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
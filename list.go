@@ -0,0 +1,145 @@
+package intertype
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// Slot is a place that holds empty interface{} values and can be
+// annotated, e.g. a function parameter.
+type Slot struct {
+	Matcher string
+	Kind    string // as in the comments of intertype.yaml, e.g. "Function arg"
+	Type    string // the signature of the function, or the type of the slot
+}
+
+// ListSlots returns the slots declared in the scope of pkg, with their
+// matchers: function and method parameters and results, struct fields,
+// keys and elements of maps, slices, arrays and channels, and named
+// empty interface types. Unexported declarations are skipped unless
+// unexported is true.
+func ListSlots(pkg *types.Package, unexported bool) []Slot {
+	var slots []Slot
+	scope := pkg.Scope()
+	visible := func(obj types.Object) bool {
+		return unexported || obj.Exported()
+	}
+
+	for _, name := range scope.Names() {
+		if !visible(scope.Lookup(name)) {
+			continue
+		}
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			slots = append(slots, funcSlots(obj, "Function")...)
+		case *types.TypeName:
+			if obj.IsAlias() {
+				continue
+			}
+			slots = append(slots, typeSlots(obj.Type(), visible)...)
+
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				for i := 0; i < iface.NumMethods(); i++ {
+					if visible(iface.Method(i)) {
+						slots = append(slots, funcSlots(iface.Method(i), "Interface method")...)
+					}
+				}
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				if visible(named.Method(i)) {
+					slots = append(slots, funcSlots(named.Method(i), "Method")...)
+				}
+			}
+		}
+	}
+	return slots
+}
+
+func funcSlots(fn *types.Func, kind string) []Slot {
+	var slots []Slot
+	sig := fn.Type().(*types.Signature)
+
+	for i := 0; i < sig.Params().Len(); i++ {
+		typ := sig.Params().At(i).Type()
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			typ = typ.(*types.Slice).Elem()
+		}
+		if isEmptyInterface(typ) {
+			slots = append(slots, Slot{
+				Matcher: fmt.Sprintf("[Params, %d] %s", i, fn.FullName()),
+				Kind:    kind + " arg",
+				Type:    sig.String(),
+			})
+		}
+	}
+
+	for i := 0; i < sig.Results().Len(); i++ {
+		if isEmptyInterface(sig.Results().At(i).Type()) {
+			slots = append(slots, Slot{
+				Matcher: fmt.Sprintf("[Returns, %d] %s", i, fn.FullName()),
+				Kind:    kind + " result",
+				Type:    sig.String(),
+			})
+		}
+	}
+	return slots
+}
+
+func typeSlots(typ types.Type, visible func(types.Object) bool) []Slot {
+	var slots []Slot
+
+	switch under := typ.Underlying().(type) {
+	case *types.Interface:
+		if under.Empty() {
+			slots = append(slots, Slot{Matcher: fmt.Sprintf("[] %s", typ), Kind: "Empty interface", Type: under.String()})
+		}
+	case *types.Struct:
+		for i := 0; i < under.NumFields(); i++ {
+			field := under.Field(i)
+			if visible(field) && isEmptyInterface(field.Type()) {
+				slots = append(slots, Slot{
+					Matcher: fmt.Sprintf("[] (%s).%s", typ, field.Name()),
+					Kind:    "Struct field",
+					Type:    field.Type().String(),
+				})
+			}
+		}
+	case *types.Map:
+		if isEmptyInterface(under.Key()) {
+			slots = append(slots, Slot{Matcher: fmt.Sprintf("[Key] %s", typ), Kind: "Map key", Type: under.String()})
+		}
+	}
+
+	if elem := elemType(typ); elem != nil && isEmptyInterface(elem) {
+		slots = append(slots, Slot{Matcher: fmt.Sprintf("[Elem] %s", typ), Kind: "Element", Type: typ.Underlying().String()})
+	}
+	return slots
+}
+
+// isEmptyInterface reports whether typ is interface{}, or a type declared
+// as interface{}.
+func isEmptyInterface(typ types.Type) bool {
+	iface, ok := typ.Underlying().(*types.Interface)
+	return ok && iface.Empty()
+}
+
+// elemType returns the type of the elements of a map, slice, array or
+// channel type, or nil.
+func elemType(typ types.Type) types.Type {
+	switch typ := typ.Underlying().(type) {
+	case *types.Map:
+		return typ.Elem()
+	case *types.Slice:
+		return typ.Elem()
+	case *types.Array:
+		return typ.Elem()
+	case *types.Chan:
+		return typ.Elem()
+	}
+	return nil
+}
@@ -3,12 +3,14 @@ package intertype
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"os"
 	"path/filepath"
 	"strconv"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/types/typeutil"
 )
@@ -25,8 +27,12 @@ var DefaultPasses = []Passer{
 	ExtSwitchStmt{},
 	ExtCompositeLitStruct{},
 	ExtCompositeLitMap{},
+	ExtCompositeLitSlice{},
+	ExtAppendCopy{},
 	ExtIndexExpr{},
 	ExtSendStmt{},
+	ExtValueSwitchStmt{},
+	ExtRangeStmt{},
 }
 
 type ExtCallExpr struct{}
@@ -36,21 +42,127 @@ type ExtReturnStmt struct{}
 type ExtSwitchStmt struct{}
 type ExtCompositeLitStruct struct{}
 type ExtCompositeLitMap struct{}
+type ExtCompositeLitSlice struct{}
+type ExtAppendCopy struct{}
 type ExtIndexExpr struct{}
 type ExtSendStmt struct{}
+type ExtValueSwitchStmt struct{}
+type ExtRangeStmt struct{}
+
+// diagnostic is a violation logged by the passes, with its severity, which
+// analysis.Diagnostic does not have, and its finding.
+type diagnostic struct {
+	analysis.Diagnostic
+	severity string
+	findings []Finding
+}
 
 func (an *Analyzer) logError(fset *token.FileSet, pos token.Pos, err error) {
-	an.AnalysisPass.Reportf(pos, "%v", err)
+	if an.summarizing {
+		return
+	}
+	for _, err := range errorList(err) {
+		if an.suppressed(pos, err) {
+			an.explainf("report at %s, suppressed: %v", an.position(pos), err)
+			continue
+		}
+		if an.baselined(pos, err) {
+			an.explainf("report at %s, in the baseline: %v", an.position(pos), err)
+			continue
+		}
+		an.explainf("report at %s: %v", an.position(pos), err)
+		finding := an.addFinding(pos, err)
+		an.diagnostics = append(an.diagnostics, diagnostic{
+			Diagnostic: analysis.Diagnostic{
+				Pos:            pos,
+				Category:       category(err),
+				Message:        err.Error(),
+				SuggestedFixes: an.suggestedFixes(err),
+				Related:        an.related(err),
+			},
+			severity: severity(err),
+			findings: []Finding{finding},
+		})
+	}
 	// fmt.Printf("%v %v\n",
 	// 	Path(fset.Position(pos)),
 	// 	err,
 	// )
 }
 
+// reportDiagnostics reports the violations logged by the passes, with the
+// ones found at the same position, e.g. for several arguments of a call,
+// grouped into one diagnostic and duplicates removed. Only the violations
+// of the same category and severity are grouped, and the ones with
+// suggested fixes are not, so that each fix stays with its violation.
+//
+// The severities are recorded in the Diagnostics of the result. Drivers
+// other than the one of the intertype command do not see them, so the
+// messages of warnings and infos start with their severity, as in the
+// text output of the intertype command.
+func (an *Analyzer) reportDiagnostics() {
+	var groups []*diagnostic
+	byKey := make(map[string]*diagnostic)
+	seen := make(map[string]bool)
+
+	for _, diag := range an.diagnostics {
+		key := fmt.Sprintf("%d\n%s", diag.Pos, diag.Message)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		groupKey := fmt.Sprintf("%d\n%s\n%s", diag.Pos, diag.Category, diag.severity)
+		group, ok := byKey[groupKey]
+		if !ok || len(group.SuggestedFixes) > 0 || len(diag.SuggestedFixes) > 0 {
+			diag := diag
+			if len(diag.SuggestedFixes) == 0 {
+				byKey[groupKey] = &diag
+			}
+			groups = append(groups, &diag)
+			continue
+		}
+		group.Message += "; " + diag.Message
+		group.findings = append(group.findings, diag.findings...)
+		for _, rel := range diag.Related {
+			if !hasRelated(group.Related, rel) {
+				group.Related = append(group.Related, rel)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if rootPaths == nil && group.severity != "error" {
+			group.Message = group.severity + ": " + group.Message
+		}
+		an.AnalysisPass.Report(group.Diagnostic)
+		an.reported = append(an.reported, Diagnostic{
+			Pos:      group.Pos,
+			Category: group.Category,
+			Severity: group.severity,
+			Findings: group.findings,
+		})
+	}
+	an.diagnostics = nil
+}
+
+func hasRelated(related []analysis.RelatedInformation, rel analysis.RelatedInformation) bool {
+	for i := range related {
+		if related[i].Pos == rel.Pos && related[i].Message == rel.Message {
+			return true
+		}
+	}
+	return false
+}
+
 func (ExtCompositeLitStruct) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
 	switch node := node.(type) {
 	case *ast.CompositeLit:
-		typ := typesInfo.TypeOf(node.Type).Underlying()
+		litTyp := typesInfo.TypeOf(node)
+		if litTyp == nil {
+			return
+		}
+		typ := derefType(litTyp).Underlying()
 
 		switch typ := typ.(type) {
 		case *types.Struct:
@@ -61,10 +173,11 @@ func (ExtCompositeLitStruct) Pass(analyzer *Analyzer, typesInfo *types.Info, fse
 			for i := 0; i < typ.NumFields(); i++ {
 				field := typ.Field(i)
 
-				rhsType := valueTypeForStructLit(typesInfo, field, i, node.Elts)
-				if rhsType == nil {
+				rhs := valueForStructLit(field, i, node.Elts)
+				if rhs == nil {
 					continue
 				}
+				rhsType := typesInfo.TypeOf(rhs)
 
 				// matcher := fmt.Sprintf("[] (%s).%s %s",
 				// 	typesInfo.TypeOf(node.Type),
@@ -72,12 +185,12 @@ func (ExtCompositeLitStruct) Pass(analyzer *Analyzer, typesInfo *types.Info, fse
 				// 	field.Type(),
 				// )
 				matcher := fmt.Sprintf("[] (%s).%s",
-					typesInfo.TypeOf(node.Type),
+					derefType(litTyp),
 					field.Name(),
 					// field.Type(),
 				)
 
-				if err := analyzer.CheckMatcher(matcher, field.Type(), rhsType); err != nil {
+				if err := analyzer.CheckMatcherExpr(matcher, field.Type(), rhsType, rhs); err != nil {
 					analyzer.logError(fset, node.Pos(), err)
 				}
 
@@ -102,9 +215,12 @@ func (ExtIndexExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.
 		// However, both of these the check the type
 		// of the index's Key
 
-		wholeType := typesInfo.TypeOf(node.X).Underlying()
+		wholeType, isMap := typesInfo.TypeOf(node.X).Underlying().(*types.Map)
+		if !isMap {
+			return
+		}
 		rhsType := typesInfo.TypeOf(node.Index)
-		lhsType := wholeType.(*types.Map).Key()
+		lhsType := wholeType.Key()
 
 		{
 			// matcher := fmt.Sprintf("[] %s %s",
@@ -115,7 +231,7 @@ func (ExtIndexExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.
 				lhsType,
 				// lhsType.Underlying(),
 			)
-			if err := analyzer.CheckMatcher(matcher, lhsType, rhsType); err != nil {
+			if err := analyzer.CheckMatcherExpr(matcher, lhsType, rhsType, node.Index); err != nil {
 				analyzer.logError(fset, node.Index.Pos(), err)
 			}
 		}
@@ -129,7 +245,7 @@ func (ExtIndexExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.
 				typesInfo.TypeOf(node.X),
 				// typesInfo.TypeOf(node.X).Underlying(),
 			)
-			if err := analyzer.CheckMatcher(matcher, lhsType, rhsType); err != nil {
+			if err := analyzer.CheckMatcherExpr(matcher, lhsType, rhsType, node.Index); err != nil {
 				analyzer.logError(fset, node.Index.Pos(), err)
 			}
 		}
@@ -139,7 +255,10 @@ func (ExtIndexExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.
 func (ExtCompositeLitMap) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
 	switch node := node.(type) {
 	case *ast.CompositeLit:
-		typ := typesInfo.TypeOf(node.Type)
+		typ := typesInfo.TypeOf(node)
+		if typ == nil {
+			return
+		}
 
 		switch typp := typ.Underlying().(type) {
 		case *types.Map:
@@ -154,7 +273,7 @@ func (ExtCompositeLitMap) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *
 
 					// matcher := fmt.Sprintf("[Key] %s %s", typ, typ.Underlying())
 					matcher := fmt.Sprintf("[Key] %s", typ)
-					if err := analyzer.CheckMatcher(matcher, typp.Key(), rhsTyp); err != nil {
+					if err := analyzer.CheckMatcherExpr(matcher, typp.Key(), rhsTyp, rhs); err != nil {
 						analyzer.logError(fset, rhs.Pos(), err)
 					}
 				}
@@ -165,7 +284,7 @@ func (ExtCompositeLitMap) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *
 
 					// matcher := fmt.Sprintf("[Elem] %s %s", typ, typ.Underlying())
 					matcher := fmt.Sprintf("[Elem] %s", typ)
-					if err := analyzer.CheckMatcher(matcher, typp.Key(), rhsTyp); err != nil {
+					if err := analyzer.CheckMatcherExpr(matcher, typp.Elem(), rhsTyp, rhs); err != nil {
 						analyzer.logError(fset, rhs.Pos(), err)
 					}
 				}
@@ -174,6 +293,89 @@ func (ExtCompositeLitMap) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *
 	}
 }
 
+func (ExtCompositeLitSlice) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
+	switch node := node.(type) {
+	case *ast.CompositeLit:
+		typ := typesInfo.TypeOf(node)
+		if typ == nil {
+			return
+		}
+
+		elemTyp := sliceElem(typ)
+		if elemTyp == nil {
+			return
+		}
+
+		for jj := range node.Elts {
+			rhs := node.Elts[jj]
+			if keyValue, ok := rhs.(*ast.KeyValueExpr); ok {
+				// eg [...]XX{5: true}
+				rhs = keyValue.Value
+			}
+			analyzer.checkSliceElem(fset, rhs.Pos(), typ, elemTyp, typesInfo.TypeOf(rhs), rhs)
+		}
+	}
+}
+
+func (ExtAppendCopy) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
+	switch node := node.(type) {
+	case *ast.CallExpr:
+		switch builtinName(typesInfo, node) {
+		case "append":
+			// append(xs, a, b) or append(xs, ys...)
+			typ := typesInfo.TypeOf(node)
+			elemTyp := sliceElem(typ)
+			if elemTyp == nil || len(node.Args) < 2 {
+				return
+			}
+
+			if node.Ellipsis.IsValid() {
+				rhs := node.Args[1]
+				analyzer.checkSliceElem(fset, node.Lparen, typ, elemTyp, sliceElem(typesInfo.TypeOf(rhs)), nil)
+				return
+			}
+
+			for _, rhs := range node.Args[1:] {
+				analyzer.checkSliceElem(fset, node.Lparen, typ, elemTyp, typesInfo.TypeOf(rhs), rhs)
+			}
+		case "copy":
+			// copy(dst, src)
+			if len(node.Args) != 2 {
+				return
+			}
+			typ := typesInfo.TypeOf(node.Args[0])
+			elemTyp := sliceElem(typ)
+			if elemTyp == nil {
+				return
+			}
+			rhs := node.Args[1]
+			analyzer.checkSliceElem(fset, node.Lparen, typ, elemTyp, sliceElem(typesInfo.TypeOf(rhs)), nil)
+		}
+	}
+}
+
+// checkSliceElem checks a value of type rhsTyp stored in a slice or array
+// of type typ, against both the "[] elem" and the "[Elem] typ" matchers.
+func (an *Analyzer) checkSliceElem(fset *token.FileSet, pos token.Pos, typ, elemTyp, rhsTyp types.Type, rhs ast.Expr) {
+	if rhsTyp == nil {
+		return
+	}
+
+	{
+		matcher := fmt.Sprintf("[] %s", elemTyp)
+		if err := an.CheckMatcherExpr(matcher, elemTyp, rhsTyp, rhs); err != nil {
+			an.logError(fset, pos, err)
+		}
+	}
+
+	{
+		matcher := fmt.Sprintf("[Elem] %s", typ)
+		if err := an.CheckMatcherExpr(matcher, elemTyp, rhsTyp, rhs); err != nil {
+			an.logError(fset, pos, err)
+		}
+	}
+}
+
 func (ExtSwitchStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, file *ast.File) {
 	switch node := node.(type) {
 	case *ast.TypeSwitchStmt:
@@ -191,9 +393,21 @@ func (ExtSwitchStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 		// 	)
 		// }
 		if err := analyzer.CheckSwitchStmt(matcher, lhsTyp, rhsTyps, hasDefaultCase); err != nil {
-			analyzer.logError(fset, node.Pos(), err)
+			analyzer.logError(fset, node.Pos(), &nodeError{err: err, node: node})
 		}
 		// }
+		analyzer.observeAssert(expr, rhsTyps, node.Pos())
+
+		if isRecoverResult(typesInfo, file, expr) {
+			// recover returns nil when there is no panic, which is usually
+			// checked before the switch, so the nil case is not required
+			rhsTyps := append(rhsTyps, types.Typ[types.UntypedNil])
+
+			matcher := "[Returns, 0] builtin.recover"
+			if err := analyzer.CheckSwitchStmt(matcher, lhsTyp, rhsTyps, hasDefaultCase); err != nil {
+				analyzer.logError(fset, node.Pos(), &nodeError{err: err, node: node})
+			}
+		}
 
 	case *ast.TypeAssertExpr:
 		rhs := node.Type
@@ -208,7 +422,96 @@ func (ExtSwitchStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 
 		// matcher := fmt.Sprintf("[] %s %s", lhsTyp, lhsTyp.Underlying())
 		matcher := fmt.Sprintf("[] %s", lhsTyp)
-		if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyp); err != nil {
+		if err := analyzer.CheckTypeAssert(matcher, lhsTyp, rhsTyp); err != nil {
+			analyzer.logError(fset, node.Pos(), err)
+		}
+		analyzer.observeAssert(expr, []types.Type{rhsTyp}, node.Pos())
+
+		if isRecoverResult(typesInfo, file, expr) {
+			matcher := "[Returns, 0] builtin.recover"
+			if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyp); err != nil {
+				analyzer.logError(fset, node.Pos(), err)
+			}
+		}
+	}
+}
+
+// isRecoverResult reports whether expr is a call to recover, or a local
+// variable that is only assigned the result of recover, as in
+//
+//	if r := recover(); r != nil {
+//		switch r.(type) {
+//		}
+//	}
+func isRecoverResult(typesInfo *types.Info, file *ast.File, expr ast.Expr) bool {
+	isRecoverCall := func(expr ast.Expr) bool {
+		call, ok := unparen(expr).(*ast.CallExpr)
+		return ok && builtinFullName(typesInfo, call) == "builtin.recover"
+	}
+
+	if isRecoverCall(expr) {
+		return true
+	}
+
+	ident, ok := unparen(expr).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	v, ok := typesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return false
+	}
+
+	path, _ := astutil.PathEnclosingInterval(file, v.Pos(), v.Pos())
+	if len(path) < 2 {
+		return false
+	}
+
+	var rhs ast.Expr
+	switch decl := path[1].(type) {
+	case *ast.AssignStmt:
+		for i := range decl.Lhs {
+			if decl.Lhs[i] == path[0] && len(decl.Lhs) == len(decl.Rhs) {
+				rhs = decl.Rhs[i]
+			}
+		}
+	case *ast.ValueSpec:
+		for i := range decl.Names {
+			if decl.Names[i] == path[0] && len(decl.Names) == len(decl.Values) {
+				rhs = decl.Values[i]
+			}
+		}
+	}
+	if rhs == nil || !isRecoverCall(rhs) {
+		return false
+	}
+
+	for i := range path {
+		var body *ast.BlockStmt
+		switch fn := path[i].(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		default:
+			continue
+		}
+		return !reassignedVars(typesInfo, body)[v]
+	}
+	return false
+}
+
+func (ExtValueSwitchStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, file *ast.File) {
+	switch node := node.(type) {
+	case *ast.SwitchStmt:
+		if node.Tag == nil {
+			break
+		}
+		tagTyp := typesInfo.TypeOf(node.Tag)
+		caseValues, hasDefaultCase := ValuesInSwitch(typesInfo, node)
+
+		matcher := fmt.Sprintf("[] %s", tagTyp)
+		if err := analyzer.CheckValueSwitch(matcher, tagTyp, caseValues, hasDefaultCase); err != nil {
 			analyzer.logError(fset, node.Pos(), err)
 		}
 	}
@@ -224,6 +527,7 @@ func (ExtReturnStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 
 		var rhsTyps []types.Type
 		var lhsTyps []types.Type
+		var rhsExprs []ast.Expr
 
 		for i := range node.Results {
 			typ := typesInfo.TypeOf(node.Results[i])
@@ -231,25 +535,29 @@ func (ExtReturnStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 		}
 
 		path, _ := astutil.PathEnclosingInterval(file, node.Pos(), node.Pos())
-		var ft *ast.FuncType
+		var ft types.Type
 		var ftt *types.Func
 	Q:
 		for i := range path {
 			switch funcDeclOrLit := path[i].(type) {
 			case *ast.FuncLit:
-				ft = funcDeclOrLit.Type
+				ft = typesInfo.TypeOf(funcDeclOrLit)
 			case *ast.FuncDecl:
-				ft = funcDeclOrLit.Type
-				ftt = typesInfo.Defs[funcDeclOrLit.Name].(*types.Func)
+				ftt, _ = typesInfo.Defs[funcDeclOrLit.Name].(*types.Func)
+				if ftt != nil {
+					ft = ftt.Type()
+				}
 			}
 
 			if ft == nil {
 				continue
 			}
 
-			for i := range ft.Results.List {
-				typ := typesInfo.TypeOf(ft.Results.List[i].Type)
-				lhsTyps = append(lhsTyps, typ)
+			if sig, ok := ft.(*types.Signature); ok {
+				results := sig.Results()
+				for i := 0; i < results.Len(); i++ {
+					lhsTyps = append(lhsTyps, results.At(i).Type())
+				}
 			}
 			break Q
 		}
@@ -264,12 +572,22 @@ func (ExtReturnStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 			}
 		}
 
+		if len(rhsTyps) != len(lhsTyps) {
+			break
+		}
+
+		if len(node.Results) == len(rhsTyps) {
+			rhsExprs = node.Results
+		} else {
+			rhsExprs = make([]ast.Expr, len(rhsTyps))
+		}
+
 		for i := range rhsTyps {
 			// TODO what if returning a function call that returns a tuple
 
 			// matcher := fmt.Sprintf("[] %s %s", lhsTyps[i], lhsTyps[i].Underlying())
 			matcher := fmt.Sprintf("[] %s", lhsTyps[i])
-			if err := analyzer.CheckMatcher(matcher, lhsTyps[i], rhsTyps[i]); err != nil {
+			if err := analyzer.CheckMatcherExpr(matcher, lhsTyps[i], rhsTyps[i], rhsExprs[i]); err != nil {
 				analyzer.logError(fset, node.Pos(), err)
 			}
 
@@ -284,7 +602,7 @@ func (ExtReturnStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 					ftt.FullName(),
 					// ftt.Type(),
 				)
-				if err := analyzer.CheckMatcher(matcher, lhsTyps[i], rhsTyps[i]); err != nil {
+				if err := analyzer.CheckMatcherExpr(matcher, lhsTyps[i], rhsTyps[i], rhsExprs[i]); err != nil {
 					analyzer.logError(fset, node.Pos(), err)
 				}
 			}
@@ -308,7 +626,7 @@ func (ExtValueSpec) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.
 		matcher := fmt.Sprintf("[] %s", lhsTyp)
 
 		for i := range rhsTyps {
-			if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyps[i]); err != nil {
+			if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, rhsTyps[i], node.Values[i]); err != nil {
 				analyzer.logError(fset, node.Pos(), err)
 			}
 		}
@@ -327,6 +645,11 @@ func (ExtAssignStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 				continue
 			}
 
+			var rhs ast.Expr
+			if len(node.Lhs) == len(node.Rhs) {
+				rhs = node.Rhs[i]
+			}
+
 			switch xxLhsi := node.Lhs[i].(type) {
 			case *ast.SelectorExpr:
 				{
@@ -341,24 +664,37 @@ func (ExtAssignStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 						// lhsTyp.Underlying(),
 					)
 
-					if err := analyzer.CheckMatcher(matcher, lhsTyp, valueTypes[i]); err != nil {
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, valueTypes[i], rhs); err != nil {
 						analyzer.logError(fset, node.Pos(), err)
 					}
 				}
-				{
-					lhsTyp := typesInfo.TypeOf(xxLhsi.X)
-
+				if owner := fieldOwner(typesInfo, xxLhsi); owner != nil {
 					// matcher := fmt.Sprintf("[] (%s).%s %s",
-					// 	lhsTyp,
+					// 	owner,
 					// 	xxLhsi.Sel,
 					// 	typesInfo.TypeOf(xxLhsi.Sel),
 					// )
 					matcher := fmt.Sprintf("[] (%s).%s",
-						lhsTyp,
+						owner,
 						xxLhsi.Sel,
 						// typesInfo.TypeOf(xxLhsi.Sel),
 					)
-					if err := analyzer.CheckMatcher(matcher, lhsTyp, valueTypes[i]); err != nil {
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, valueTypes[i], rhs); err != nil {
+						analyzer.logError(fset, node.Pos(), err)
+					}
+				}
+			case *ast.IndexExpr:
+				// the key of a map is checked by ExtIndexExpr, which
+				// visits the index expressions on both sides
+				{
+					matcher := fmt.Sprintf("[] %s", lhsTyp)
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, valueTypes[i], rhs); err != nil {
+						analyzer.logError(fset, node.Pos(), err)
+					}
+				}
+				if hasElem(typesInfo.TypeOf(xxLhsi.X)) {
+					matcher := fmt.Sprintf("[Elem] %s", typesInfo.TypeOf(xxLhsi.X))
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, valueTypes[i], rhs); err != nil {
 						analyzer.logError(fset, node.Pos(), err)
 					}
 				}
@@ -372,7 +708,7 @@ func (ExtAssignStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token
 					// lhsTyp.Underlying(),
 				)
 
-				if err := analyzer.CheckMatcher(matcher, lhsTyp, valueTypes[i]); err != nil {
+				if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, valueTypes[i], rhs); err != nil {
 					analyzer.logError(fset, node.Pos(), err)
 				}
 			}
@@ -385,8 +721,10 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 	switch node := node.(type) {
 	case *ast.CallExpr:
 		funType := typesInfo.TypeOf(node.Fun)
-		sig, isSig := funType.(*types.Signature)
-		if !isSig {
+		if funType == nil {
+			return
+		}
+		if typesInfo.Types[node.Fun].IsType() {
 			// it is a conversion like T1(expr)
 			// it is not a signature,
 			// eg it might be a []byte(...)
@@ -395,15 +733,42 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 
 			// TODO: handle conversions of types in analyzer.Annots
 
+			if len(node.Args) != 1 {
+				return
+			}
+
 			// matcher := fmt.Sprintf("[] %s %s", funType, funType.Underlying())
 			matcher := fmt.Sprintf("[] %s", funType)
-			if err := analyzer.CheckMatcher(matcher, funType, typesInfo.TypeOf(node.Args[0])); err != nil {
+			if err := analyzer.CheckMatcherExpr(matcher, funType, typesInfo.TypeOf(node.Args[0]), node.Args[0]); err != nil {
 				analyzer.logError(fset, node.Lparen, err)
 			}
 
 			return
 		}
 
+		switch builtinName(typesInfo, node) {
+		case "append", "copy":
+			// handled by ExtAppendCopy
+			return
+		}
+
+		// calleeName is the name used in the "[] name" and "[Params, i] name"
+		// matchers, eg context.WithValue or builtin.panic
+		var calleeName string
+		if typesInfo.Types[node.Fun].IsBuiltin() {
+			calleeName = builtinFullName(typesInfo, node)
+		} else if fn, ok := typeutil.Callee(typesInfo, node).(*types.Func); ok {
+			calleeName = fn.FullName()
+		} else if fn := analyzer.funcValueCallee(node); fn != nil {
+			// eg f(ctx, 1, 2) after f := context.WithValue
+			calleeName = fn.FullName()
+		}
+
+		sig, isSig := funType.Underlying().(*types.Signature)
+		if !isSig {
+			return
+		}
+
 		{
 			params := sig.Params()
 			var paramsVars []*types.Var
@@ -417,13 +782,19 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 
 			if sig.Variadic() {
 				variadicIdx = params.Len() - 1
-				variadicTyp = params.At(variadicIdx).Type().(*types.Slice).Elem()
+				if slice, ok := params.At(variadicIdx).Type().Underlying().(*types.Slice); ok {
+					variadicTyp = slice.Elem()
+				} else {
+					// eg append([]byte, string...)
+					variadicTyp = params.At(variadicIdx).Type()
+				}
 			}
 
 			var rhsTyps []types.Type
 			for i := range node.Args {
 				rhsTyps = append(rhsTyps, typesInfo.Types[node.Args[i]].Type)
 			}
+			rhsExprs := node.Args
 
 			// DONE: what if a function that returns a tuple is passed to a
 			//       a) non-variadic function
@@ -436,10 +807,22 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 					for i := 0; i < tuple.Len(); i++ {
 						rhsTyps[i] = tuple.At(i).Type()
 					}
+					rhsExprs = make([]ast.Expr, tuple.Len())
 				}
 			}
 
+			if !sig.Variadic() && len(rhsTyps) > len(paramsVars) {
+				// eg f(1, 2) for func f(a int), in a package that does not
+				// type-check
+				return
+			}
+
 			for i := range rhsTyps {
+				if rhsExprs[i] != nil && typesInfo.Types[rhsExprs[i]].IsType() {
+					// eg make([]T, n) or new(T)
+					continue
+				}
+
 				var lhsTyp types.Type
 				if !sig.Variadic() {
 					lhsTyp = paramsVars[i].Type()
@@ -453,24 +836,26 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 
 				// matcher := fmt.Sprintf("[] %s %s", lhsTyp, lhsTyp.Underlying())
 				matcher := fmt.Sprintf("[] %s", lhsTyp)
-				if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyps[i]); err != nil {
+				if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, rhsTyps[i], rhsExprs[i]); err != nil {
 					analyzer.logError(fset, node.Lparen, err)
 				}
 			}
 
-			fn, hasCallee := typeutil.Callee(typesInfo, node).(*types.Func)
-			if fn != nil && hasCallee {
+			if calleeName != "" {
 
 				var lhsTyps []types.Type
 
 				for i := range rhsTyps {
 					var lhsTyp types.Type
-					if !sig.Variadic() {
+					switch {
+					case calleeName == "builtin.print" || calleeName == "builtin.println" || calleeName == "builtin.panic":
+						// the signature recorded for these has the
+						// types of the arguments, not interface{}
+						lhsTyp = types.NewInterfaceType(nil, nil).Complete()
+					case !sig.Variadic() || i < variadicIdx:
 						lhsTyp = paramsVars[i].Type()
-					} else {
-						if i >= variadicIdx {
-							lhsTyp = variadicTyp
-						}
+					default:
+						lhsTyp = variadicTyp
 					}
 					lhsTyps = append(lhsTyps, lhsTyp)
 				}
@@ -480,7 +865,7 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 				// 	fn.Type(),
 				// )
 				matcher := fmt.Sprintf("[] %s",
-					fn.FullName(),
+					calleeName,
 					// fn.Type(),
 				)
 				if err := analyzer.CheckMatcherMultiple(matcher, lhsTyps, rhsTyps); err != nil {
@@ -488,6 +873,9 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 				}
 
 				for i := range rhsTyps {
+					if rhsExprs[i] != nil && typesInfo.Types[rhsExprs[i]].IsType() {
+						continue
+					}
 					lhsTyp := lhsTyps[i]
 
 					// matcher := fmt.Sprintf("[Params, %d] %s %s",
@@ -497,11 +885,11 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 					// )
 					matcher := fmt.Sprintf("[Params, %d] %s",
 						i,
-						fn.FullName(),
+						calleeName,
 						// fn.Type(),
 					)
 					// fmt.Printf(">>> [debug passes.go:454] matcher: %+v\n", matcher)
-					if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyps[i]); err != nil {
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, rhsTyps[i], rhsExprs[i]); err != nil {
 						analyzer.logError(fset, node.Lparen, err)
 					}
 				}
@@ -513,7 +901,15 @@ func (ExtCallExpr) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 func (ExtSendStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
 	switch node := node.(type) {
 	case *ast.SendStmt:
-		lhsTyp := typesInfo.Types[node.Chan].Type.(*types.Chan).Elem()
+		chanTyp := typesInfo.TypeOf(node.Chan)
+		if chanTyp == nil {
+			return
+		}
+		ch, isChan := chanTyp.Underlying().(*types.Chan)
+		if !isChan {
+			return
+		}
+		lhsTyp := ch.Elem()
 		rhsTyp := typesInfo.Types[node.Value].Type
 
 		// matcher := fmt.Sprintf("[] %s %s",
@@ -524,14 +920,187 @@ func (ExtSendStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.F
 			lhsTyp,
 			// lhsTyp.Underlying(),
 		)
-		if err := analyzer.CheckMatcher(matcher, lhsTyp, rhsTyp); err != nil {
+		if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, rhsTyp, node.Value); err != nil {
 			analyzer.logError(fset, node.Pos(), err)
 		}
 
+		{
+			matcher := fmt.Sprintf("[Elem] %s", chanTyp)
+			if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, rhsTyp, node.Value); err != nil {
+				analyzer.logError(fset, node.Pos(), err)
+			}
+		}
+	}
+}
+
+func (ExtRangeStmt) Pass(analyzer *Analyzer, typesInfo *types.Info, fset *token.FileSet, node ast.Node, f *ast.File) {
+	switch node := node.(type) {
+	case *ast.RangeStmt:
+		if node.Tok != token.ASSIGN {
+			// with :=, the variables have the types of the ranged values
+			return
+		}
+
+		keyTyp, valueTyp := rangeTypes(typesInfo.TypeOf(node.X))
+
+		for _, lhsAndRhs := range []struct {
+			lhs    ast.Expr
+			rhsTyp types.Type
+		}{
+			{node.Key, keyTyp},
+			{node.Value, valueTyp},
+		} {
+			if lhsAndRhs.lhs == nil || lhsAndRhs.rhsTyp == nil {
+				continue
+			}
+			lhsTyp := typesInfo.TypeOf(lhsAndRhs.lhs)
+			if lhsTyp == nil {
+				continue
+			}
+
+			// matcher := fmt.Sprintf("[] %s %s", lhsTyp, lhsTyp.Underlying())
+			matcher := fmt.Sprintf("[] %s", lhsTyp)
+			if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, lhsAndRhs.rhsTyp, nil); err != nil {
+				analyzer.logError(fset, lhsAndRhs.lhs.Pos(), err)
+			}
+
+			if sel, ok := lhsAndRhs.lhs.(*ast.SelectorExpr); ok {
+				if owner := fieldOwner(typesInfo, sel); owner != nil {
+					matcher := fmt.Sprintf("[] (%s).%s", owner, sel.Sel)
+					if err := analyzer.CheckMatcherExpr(matcher, lhsTyp, lhsAndRhs.rhsTyp, nil); err != nil {
+						analyzer.logError(fset, lhsAndRhs.lhs.Pos(), err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// fieldOwner returns the type that declares the field selected by sel,
+// following pointers and embedded fields, e.g. for a.b.Field it is the
+// struct type of the Field declaration, not the type of a.b.
+// It returns nil if sel is not a field selection.
+func fieldOwner(typesInfo *types.Info, sel *ast.SelectorExpr) types.Type {
+	selection, ok := typesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.FieldVal {
+		return nil
+	}
+
+	owner := derefType(selection.Recv())
+	index := selection.Index()
+	for _, idx := range index[:len(index)-1] {
+		structTyp, ok := owner.Underlying().(*types.Struct)
+		if !ok {
+			return nil
+		}
+		owner = derefType(structTyp.Field(idx).Type())
+	}
+
+	return owner
+}
+
+// sliceElem returns the element type of a slice or array type,
+// or byte for strings, as in append([]byte, string...).
+// It returns nil for other types.
+func sliceElem(typ types.Type) types.Type {
+	if typ == nil {
+		return nil
+	}
+	switch typ := typ.Underlying().(type) {
+	case *types.Slice:
+		return typ.Elem()
+	case *types.Array:
+		return typ.Elem()
+	case *types.Basic:
+		if typ.Info()&types.IsString != 0 {
+			return types.Typ[types.Byte]
+		}
+	}
+	return nil
+}
+
+// rangeTypes returns the types of the key and value of a range over a
+// value of type typ. They are nil if there is no key or value.
+func rangeTypes(typ types.Type) (keyTyp, valueTyp types.Type) {
+	if typ == nil {
+		return nil, nil
+	}
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		// pointer to array
+		typ = ptr.Elem()
+	}
+	switch typ := typ.Underlying().(type) {
+	case *types.Map:
+		return typ.Key(), typ.Elem()
+	case *types.Slice:
+		return types.Typ[types.Int], typ.Elem()
+	case *types.Array:
+		return types.Typ[types.Int], typ.Elem()
+	case *types.Chan:
+		return typ.Elem(), nil
+	case *types.Basic:
+		if typ.Info()&types.IsString != 0 {
+			return types.Typ[types.Int], types.Typ[types.Rune]
+		}
+		if typ.Info()&types.IsInteger != 0 {
+			return typ, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasElem reports whether typ is a map, slice, array or channel type,
+// whose elements are matched by "[Elem] typ".
+func hasElem(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch typ.Underlying().(type) {
+	case *types.Map, *types.Slice, *types.Array, *types.Chan:
+		return true
+	}
+	return false
+}
+
+// builtinName returns the name of the builtin function called by call,
+// or "" if it does not call a builtin.
+func builtinName(typesInfo *types.Info, call *ast.CallExpr) string {
+	ident, ok := unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	builtin, ok := typesInfo.Uses[ident].(*types.Builtin)
+	if !ok {
+		return ""
+	}
+	return builtin.Name()
+}
+
+// builtinFullName returns the name of the builtin function called by call
+// as used in matchers: builtin.panic for the predeclared functions and
+// unsafe.Sizeof for the ones of package unsafe.
+func builtinFullName(typesInfo *types.Info, call *ast.CallExpr) string {
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		if builtin, ok := typesInfo.Uses[fun].(*types.Builtin); ok {
+			return "builtin." + builtin.Name()
+		}
+	case *ast.SelectorExpr:
+		if builtin, ok := typesInfo.Uses[fun.Sel].(*types.Builtin); ok {
+			return "unsafe." + builtin.Name()
+		}
+	}
+	return ""
+}
+
+func derefType(typ types.Type) types.Type {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		return ptr.Elem()
 	}
+	return typ
 }
 
-func valueTypeForStructLit(typesInfo *types.Info, field *types.Var, fieldIdx int, elts []ast.Expr) types.Type {
+func valueForStructLit(field *types.Var, fieldIdx int, elts []ast.Expr) ast.Expr {
 	if len(elts) == 0 {
 		return nil
 	}
@@ -543,12 +1112,11 @@ func valueTypeForStructLit(typesInfo *types.Info, field *types.Var, fieldIdx int
 			xxElt := elts[ii]
 			keyVal := xxElt.(*ast.KeyValueExpr)
 			if field.Name() == keyVal.Key.(*ast.Ident).Name {
-				return typesInfo.TypeOf(keyVal.Value)
+				return keyVal.Value
 			}
 		}
 	} else {
-		xxElt := elts[fieldIdx]
-		return typesInfo.TypeOf(xxElt)
+		return elts[fieldIdx]
 	}
 
 	return nil
@@ -586,17 +1154,38 @@ func TypesAssertedInSwitch(typesInfo *types.Info, sw *ast.TypeSwitchStmt) ([]typ
 	return typs, hasDefaultCase
 }
 
+func ValuesInSwitch(typesInfo *types.Info, sw *ast.SwitchStmt) ([]constant.Value, bool) {
+	var values []constant.Value
+	hasDefaultCase := false
+
+	if sw.Body == nil {
+		return nil, false
+	}
+
+	for i := range sw.Body.List {
+		list := sw.Body.List[i].(*ast.CaseClause).List
+		if list == nil {
+			hasDefaultCase = true
+		}
+		for j := range list {
+			values = append(values, typesInfo.Types[list[j]].Value)
+		}
+	}
+	return values, hasDefaultCase
+}
+
+// Path returns position with its file relative to the working directory,
+// or absolute if it cannot be made relative.
 func Path(p token.Position) string {
 	if p == (token.Position{}) {
 		return "builtin"
 	}
 	pwd, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		return p.String()
 	}
-	p.Filename, err = filepath.Rel(pwd, p.Filename)
-	if err != nil {
-		panic(err)
+	if rel, err := filepath.Rel(pwd, p.Filename); err == nil {
+		p.Filename = rel
 	}
 
 	return p.String()
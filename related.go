@@ -0,0 +1,193 @@
+package intertype
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// annotationError attaches the annotation that was violated to the error
+// of a checker, and reports it as customized by the Report of the
+// annotation.
+type annotationError struct {
+	err  error
+	item YamlAnnotItem
+
+	// dynamic and annotated are the types that replace the placeholders
+	// of a custom message.
+	dynamic   string
+	annotated string
+
+	// own reports that err is a message of intertype itself, e.g. about
+	// unverified values, rather than a violation of the annotation, and
+	// category and severity are its own instead of the ones of the
+	// annotation.
+	own      bool
+	category string
+	severity string
+}
+
+func (err *annotationError) Error() string {
+	report := err.item.Check.Report
+
+	msg := err.err.Error()
+	if report.Message != "" && !err.own {
+		msg = strings.NewReplacer(
+			"{dynamic}", err.dynamic,
+			"{annotated}", err.annotated,
+		).Replace(report.Message)
+	}
+
+	if via := err.item.Via; len(via) > 0 {
+		msg = fmt.Sprintf("%s (via %s)", msg, hopsString(via))
+	}
+
+	if report.DocURL != "" && !err.own {
+		msg = fmt.Sprintf("%s, see %s", msg, report.DocURL)
+	}
+	return msg
+}
+
+func (err *annotationError) Unwrap() error {
+	return err.err
+}
+
+// categoryError is a diagnostic of intertype itself that is not about an
+// annotation, e.g. an unused suppression, with its category and severity.
+type categoryError struct {
+	err      error
+	category string
+	severity string
+}
+
+func (err *categoryError) Error() string {
+	return err.err.Error()
+}
+
+func (err *categoryError) Unwrap() error {
+	return err.err
+}
+
+// category returns the category of the annotation violated in err, or of
+// the diagnostic of intertype itself.
+func category(err error) string {
+	var catErr *categoryError
+	if errors.As(err, &catErr) {
+		return catErr.category
+	}
+	var annotErr *annotationError
+	if !errors.As(err, &annotErr) {
+		return ""
+	}
+	if annotErr.own {
+		return annotErr.category
+	}
+	return annotErr.item.Check.Report.Category
+}
+
+// severity returns the severity of the annotation violated in err, or of
+// the diagnostic of intertype itself: error, warning or info.
+func severity(err error) string {
+	var sev string
+	var catErr *categoryError
+	var annotErr *annotationError
+	switch {
+	case errors.As(err, &catErr):
+		sev = catErr.severity
+	case errors.As(err, &annotErr) && annotErr.own:
+		sev = annotErr.severity
+	case annotErr != nil:
+		sev = annotErr.item.Check.Report.Severity
+	}
+	if sev == "" {
+		return "error"
+	}
+	return sev
+}
+
+// typeNames returns the types as written in messages for annotations,
+// without package paths, separated by commas.
+func typeNames(typs []types.Type) string {
+	names := make([]string, len(typs))
+	for i := range typs {
+		names[i] = typeString(typs[i])
+	}
+	return strings.Join(names, ", ")
+}
+
+// related returns the location of the annotation violated in err, with
+// its matcher, so that it can be jumped to from the diagnostic.
+func (an *Analyzer) related(err error) []analysis.RelatedInformation {
+	var annotErr *annotationError
+	if !errors.As(err, &annotErr) || annotErr.item.Matcher == "" {
+		return nil
+	}
+
+	// the position of an annotation of another package is only known if
+	// it was analyzed with the same file set, e.g. not by go vet
+	item := annotErr.item
+	position := an.AnalysisPass.Fset.Position(item.Pos)
+	if !item.Pos.IsValid() || position.Filename != item.Source.Filename || position.Line != item.Source.Line {
+		return nil
+	}
+
+	return []analysis.RelatedInformation{
+		{Pos: item.Pos, Message: fmt.Sprintf("annotation %q declared here", item.Matcher)},
+	}
+}
+
+// setYamlPos sets the positions of the annotations parsed from
+// intertype.yaml in fset, to which the file is added once, when the first
+// package analyzed with fset is.
+func setYamlPos(fset *token.FileSet, annots map[string][]YamlAnnotItem) {
+	files := make(map[string]*token.File)
+	for _, items := range annots {
+		for i := range items {
+			filename := items[i].Source.Filename
+			file, ok := files[filename]
+			if !ok {
+				file = yamlFile(fset, filename)
+				files[filename] = file
+			}
+			if file != nil && items[i].Source.Line <= file.LineCount() {
+				items[i].Pos = file.LineStart(items[i].Source.Line)
+			}
+		}
+	}
+}
+
+// yamlFileMu serializes the lookups and additions of yamlFile, as the
+// packages are analyzed concurrently with the same file set.
+var yamlFileMu sync.Mutex
+
+// yamlFile returns the file filename of fset, and adds it if it is not
+// there yet.
+func yamlFile(fset *token.FileSet, filename string) *token.File {
+	yamlFileMu.Lock()
+	defer yamlFileMu.Unlock()
+
+	var file *token.File
+	fset.Iterate(func(f *token.File) bool {
+		if f.Name() == filename {
+			file = f
+		}
+		return file == nil
+	})
+	if file != nil {
+		return file
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	file = fset.AddFile(filename, -1, len(content))
+	file.SetLinesForContent(content)
+	return file
+}
@@ -0,0 +1,121 @@
+package intertype
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// InferSealedInterfaces adds an implicit OneOf constraint to the interfaces
+// of the analyzed package that have an unexported method, e.g.
+//
+//	type Event interface{ isEvent() }
+//
+// Only types declared in the same package can implement such interfaces,
+// so the constraint lists all of them. Types of other packages can still
+// implement them by embedding one of these types, e.g.
+//
+//	type myEvent struct{ events.Created }
+//
+// so such types are allowed as well, but type switches are not required
+// to have cases for them. Interfaces that are already annotated with
+// OneOf are left alone.
+func (an *Analyzer) InferSealedInterfaces() {
+	scope := an.AnalysisPass.Pkg.Scope()
+
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+		iface, ok := typeName.Type().Underlying().(*types.Interface)
+		if !ok || !hasUnexportedMethod(iface) {
+			continue
+		}
+
+		matcher := fmt.Sprintf("[] %s", typeName.Type())
+		if hasOneOf(an.Annots[matcher]) {
+			continue
+		}
+
+		implementers := sealedImplementers(scope, iface)
+		if len(implementers) == 0 {
+			continue
+		}
+
+		an.addItems(matcher, YamlAnnotItem{
+			Address: []string{},
+			Check:   Constraints{OneOf: implementers},
+			Matcher: matcher,
+			Source:  an.AnalysisPass.Fset.Position(typeName.Pos()),
+			Pos:     typeName.Pos(),
+			Sealed:  true,
+		})
+	}
+}
+
+// sealedSpec returns the constraints of item for values of typs assigned
+// to lhs. For an inferred sealed interface, the concrete types among typs
+// that implement lhs, e.g. by embedding one of its implementers, are
+// added to OneOf.
+func (item *YamlAnnotItem) sealedSpec(lhs types.Type, typs []types.Type) Constraints {
+	spec := item.Check
+	iface, ok := lhs.Underlying().(*types.Interface)
+	if !item.Sealed || !ok {
+		return spec
+	}
+
+	oneOf := spec.OneOf
+	for _, typ := range typs {
+		if typ == nil || types.IsInterface(typ) || !types.Implements(typ, iface) || isIncluded(typ.String(), oneOf) {
+			continue
+		}
+		if len(oneOf) == len(spec.OneOf) {
+			oneOf = append([]string(nil), spec.OneOf...)
+		}
+		oneOf = append(oneOf, typ.String())
+	}
+	spec.OneOf = oneOf
+	return spec
+}
+
+func hasUnexportedMethod(iface *types.Interface) bool {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOneOf(items []YamlAnnotItem) bool {
+	for i := range items {
+		if len(items[i].Check.OneOf) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sealedImplementers returns the concrete types in scope that implement
+// iface, either as T or as *T.
+func sealedImplementers(scope *types.Scope, iface *types.Interface) []string {
+	var implementers []string
+
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() || types.IsInterface(typeName.Type()) {
+			continue
+		}
+
+		typ := typeName.Type()
+		if types.Implements(typ, iface) {
+			implementers = append(implementers, typ.String())
+		} else if ptr := types.NewPointer(typ); types.Implements(ptr, iface) {
+			implementers = append(implementers, ptr.String())
+		}
+	}
+
+	sort.Strings(implementers)
+	return implementers
+}
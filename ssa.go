@@ -0,0 +1,159 @@
+package intertype
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// SSAInfo maps the expressions of the analyzed package to their SSA values.
+type SSAInfo struct {
+	values map[ast.Expr]ssa.Value
+}
+
+// BuildSSA builds the SSA form of the analyzed package, the same way the
+// buildssa analyzer does, but with debug information enabled so that the
+// values of source expressions can be looked up.
+func (an *Analyzer) BuildSSA() {
+	pass := an.AnalysisPass
+	prog := ssa.NewProgram(pass.Fset, ssa.BuilderMode(0))
+
+	created := make(map[*types.Package]bool)
+	var createAll func(pkgs []*types.Package)
+	createAll = func(pkgs []*types.Package) {
+		for _, p := range pkgs {
+			if !created[p] {
+				created[p] = true
+				prog.CreatePackage(p, nil, nil, true)
+				createAll(p.Imports())
+			}
+		}
+	}
+	createAll(pass.Pkg.Imports())
+
+	ssapkg := prog.CreatePackage(pass.Pkg, pass.Files, pass.TypesInfo, false)
+	ssapkg.SetDebugMode(true)
+	ssapkg.Build()
+
+	info := &SSAInfo{values: make(map[ast.Expr]ssa.Value)}
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != ssapkg {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if ref, ok := instr.(*ssa.DebugRef); ok && !ref.IsAddr {
+					info.values[ref.Expr] = ref.X
+				}
+			}
+		}
+	}
+
+	an.SSA = info
+}
+
+// DynamicTypes returns the types of the dynamic values that may flow into
+// expr. Unless expr is an interface whose dynamic types are known from the
+// SSA form, it is just the static type of expr.
+func (an *Analyzer) DynamicTypes(expr ast.Expr, typ types.Type) []types.Type {
+	if an.SSA == nil || expr == nil || typ == nil || !types.IsInterface(typ) {
+		return []types.Type{typ}
+	}
+
+	v, ok := an.SSA.values[unparen(expr)]
+	if !ok {
+		return []types.Type{typ}
+	}
+
+	typs, ok := dynamicTypes(v, make(map[ssa.Value]bool))
+	if !ok || len(typs) == 0 {
+		return []types.Type{typ}
+	}
+
+	var uniq []types.Type
+Q:
+	for i := range typs {
+		for j := range uniq {
+			if types.Identical(typs[i], uniq[j]) {
+				continue Q
+			}
+		}
+		uniq = append(uniq, typs[i])
+	}
+	return uniq
+}
+
+// dynamicTypes follows v back to the values it was made from, and returns
+// their types. It returns false if some of them are unknown, eg because
+// they are parameters or results of function calls.
+func dynamicTypes(v ssa.Value, seen map[ssa.Value]bool) ([]types.Type, bool) {
+	if seen[v] {
+		return nil, true
+	}
+	seen[v] = true
+
+	switch v := v.(type) {
+	case *ssa.MakeInterface:
+		return []types.Type{v.X.Type()}, true
+	case *ssa.ChangeInterface:
+		return dynamicTypes(v.X, seen)
+	case *ssa.Const:
+		if v.Value == nil {
+			return []types.Type{types.Typ[types.UntypedNil]}, true
+		}
+	case *ssa.TypeAssert:
+		if !v.CommaOk && !types.IsInterface(v.AssertedType) {
+			return []types.Type{v.AssertedType}, true
+		}
+	case *ssa.Phi:
+		var typs []types.Type
+		for _, edge := range v.Edges {
+			edgeTyps, ok := dynamicTypes(edge, seen)
+			if !ok {
+				return nil, false
+			}
+			typs = append(typs, edgeTyps...)
+		}
+		return typs, true
+	case *ssa.UnOp:
+		// a load from a local variable that could not be lifted,
+		// eg because its address is taken
+		alloc, ok := v.X.(*ssa.Alloc)
+		if !ok || v.Op.String() != "*" {
+			return nil, false
+		}
+		var typs []types.Type
+		for _, ref := range *alloc.Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.DebugRef:
+			case *ssa.UnOp:
+			case *ssa.Store:
+				if ref.Addr != alloc {
+					return nil, false
+				}
+				storedTyps, ok := dynamicTypes(ref.Val, seen)
+				if !ok {
+					return nil, false
+				}
+				typs = append(typs, storedTyps...)
+			default:
+				return nil, false
+			}
+		}
+		return typs, true
+	}
+
+	return nil, false
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
@@ -0,0 +1,163 @@
+package intertype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+type paramRef struct {
+	fn  *types.Func
+	idx int
+}
+
+// Summarize computes which interface parameters of the functions declared
+// in the analyzed package flow into annotated slots, e.g.
+//
+//	func wrap(v interface{}) { ctx = context.WithValue(ctx, v, 1) }
+//
+// derives the annotations of "[Params, 1] context.WithValue" for
+// "[Params, 0] pkg.wrap", so that wrap(42) is reported at the call site.
+// Derived annotations are exported like the ones declared in comments, so
+// wrappers of wrappers in other packages are checked too.
+func (an *Analyzer) Summarize() {
+	pass := an.AnalysisPass
+	an.params = make(map[*types.Var]paramRef)
+	an.derived = make(map[string]bool)
+
+	type funcDecl struct {
+		decl *ast.FuncDecl
+		file *ast.File
+	}
+	var wrappers []funcDecl
+
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Body == nil {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			reassigned := reassignedVars(pass.TypesInfo, decl.Body)
+			params := fn.Type().(*types.Signature).Params()
+			tracked := false
+			for i := 0; i < params.Len(); i++ {
+				param := params.At(i)
+				if !types.IsInterface(param.Type()) || reassigned[param] {
+					continue
+				}
+				if _, ok := an.AllowedTypes(param.Type()); ok {
+					// its values are checked against the annotation of
+					// its type instead
+					continue
+				}
+				an.params[param] = paramRef{fn: fn, idx: i}
+				tracked = true
+			}
+			if tracked {
+				wrappers = append(wrappers, funcDecl{decl: decl, file: f})
+			}
+		}
+	}
+
+	// only the functions with tracked parameters are walked, and wrappers
+	// of wrappers need another round each, so a chain of them is derived
+	// in at most as many rounds as there are such functions
+	an.summarizing = true
+	for round := 0; round < len(wrappers); round++ {
+		before := len(an.derived)
+		for _, wrapper := range wrappers {
+			an.walk(wrapper.file, wrapper.decl)
+		}
+		if len(an.derived) == before {
+			break
+		}
+	}
+	an.summarizing = false
+}
+
+// deriveParam adds the annotations of matcher, a parameter matcher like
+// "[Params, 1] context.WithValue", to the parameter rhs refers to, if it is
+// a parameter of an enclosing function declaration. It returns false if
+// rhs is not such a parameter, or matcher has no annotations. The values
+// of the parameter are then checked at the call sites instead.
+func (an *Analyzer) deriveParam(matcher string, rhs ast.Expr) bool {
+	if an.params == nil || rhs == nil || !strings.HasPrefix(matcher, "[Params, ") {
+		return false
+	}
+	ident, ok := unparen(rhs).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	param, ok := an.AnalysisPass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return false
+	}
+	ref, ok := an.params[param]
+	if !ok {
+		return false
+	}
+	annotItems, found := an.Annots[matcher]
+	if !found {
+		return false
+	}
+
+	derivedMatcher := fmt.Sprintf("[Params, %d] %s", ref.idx, ref.fn.FullName())
+	hop := Hop{
+		Param: param.Name(),
+		Func:  ref.fn.FullName(),
+		Pos:   an.AnalysisPass.Fset.Position(ident.Pos()),
+	}
+
+	for _, item := range annotItems {
+		via := item.Via
+		if len(via) == 0 {
+			via = []Hop{{Matcher: matcher}}
+		}
+		key := strings.Join([]string{derivedMatcher, hop.Pos.String(), item.Check.String()}, "\n")
+		if an.derived[key] {
+			continue
+		}
+		an.derived[key] = true
+
+		an.addItems(derivedMatcher, YamlAnnotItem{
+			Address: []string{},
+			Check:   item.Check,
+			Via:     append([]Hop{hop}, via...),
+			Matcher: item.Matcher,
+			Source:  item.Source,
+			Pos:     item.Pos,
+		})
+	}
+	return true
+}
+
+// reassignedVars returns the variables that are assigned to, or whose
+// address is taken, in body.
+func reassignedVars(typesInfo *types.Info, body *ast.BlockStmt) map[types.Object]bool {
+	vars := make(map[types.Object]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if ident, ok := unparen(lhs).(*ast.Ident); ok {
+					vars[typesInfo.Uses[ident]] = true
+				}
+			}
+		case *ast.UnaryExpr:
+			if n.Op != token.AND {
+				break
+			}
+			if ident, ok := unparen(n.X).(*ast.Ident); ok {
+				vars[typesInfo.Uses[ident]] = true
+			}
+		}
+		return true
+	})
+	return vars
+}
@@ -0,0 +1,217 @@
+package intertype
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// suppression is an ignore directive:
+//
+//	//intertype:ignore <category-or-matcher> <reason>
+//	//intertype:ignore-file <category-or-matcher> <reason>
+//	//intertype:ignore-package <category-or-matcher> <reason>
+//
+// Matchers contain spaces, so they are quoted, e.g.
+// "[Params, 1] context.WithValue".
+type suppression struct {
+	pos    token.Pos
+	target string
+	reason string
+
+	// from and to are the positions suppressed by the directive, or NoPos
+	// for the whole package.
+	from token.Pos
+	to   token.Pos
+
+	used bool
+}
+
+const (
+	ignoreDirective        = "//intertype:ignore"
+	ignoreFileDirective    = "//intertype:ignore-file"
+	ignorePackageDirective = "//intertype:ignore-package"
+)
+
+func isSuppression(text string) bool {
+	return strings.HasPrefix(text, ignoreDirective)
+}
+
+// CollectSuppressions parses the ignore directives of the analyzed package.
+// Directives without a reason are reported and do not suppress anything.
+func (an *Analyzer) CollectSuppressions() {
+	pass := an.AnalysisPass
+
+	for _, f := range pass.Files {
+		var lines *lineNodes
+		for _, cg := range f.Comments {
+			for _, comment := range cg.List {
+				if !isSuppression(comment.Text) {
+					continue
+				}
+
+				kind, target, reason, err := parseSuppression(comment.Text)
+				if err != nil {
+					pass.Reportf(comment.Pos(), "invalid suppression: %v", err)
+					continue
+				}
+
+				s := &suppression{pos: comment.Pos(), target: target, reason: reason}
+				switch kind {
+				case ignoreDirective:
+					if lines == nil {
+						lines = newLineNodes(pass.Fset, f)
+					}
+					s.from, s.to = lines.scope(comment)
+				case ignoreFileDirective:
+					s.from, s.to = f.Pos(), f.End()
+				}
+				an.suppressions = append(an.suppressions, s)
+			}
+		}
+	}
+}
+
+// parseSuppression returns the directive, target and reason of an ignore
+// directive.
+func parseSuppression(text string) (kind, target, reason string, err error) {
+	kind = strings.Fields(text)[0]
+	switch kind {
+	case ignoreDirective, ignoreFileDirective, ignorePackageDirective:
+	default:
+		return "", "", "", fmt.Errorf("unknown directive %s", kind)
+	}
+
+	rest := strings.TrimSpace(strings.TrimPrefix(text, kind))
+	if strings.HasPrefix(rest, `"`) {
+		end := strings.Index(rest[1:], `"`)
+		if end < 0 {
+			return "", "", "", fmt.Errorf("unterminated matcher in %q", text)
+		}
+		target, err = strconv.Unquote(rest[:end+2])
+		if err != nil {
+			return "", "", "", err
+		}
+		rest = rest[end+2:]
+	} else if fields := strings.Fields(rest); len(fields) > 0 {
+		target = fields[0]
+		rest = strings.TrimPrefix(rest, target)
+	}
+
+	if target == "" {
+		return "", "", "", fmt.Errorf("missing category or matcher in %q", text)
+	}
+	reason = strings.TrimSpace(rest)
+	if reason == "" {
+		return "", "", "", fmt.Errorf("missing reason for ignoring %s", target)
+	}
+	return kind, target, reason, nil
+}
+
+// suppressed reports whether the violation err at pos is ignored, by the
+// category or the matcher of the annotation it violates, or by the
+// category of a diagnostic of intertype itself.
+func (an *Analyzer) suppressed(pos token.Pos, err error) bool {
+	var matcher string
+	var annotErr *annotationError
+	if errors.As(err, &annotErr) {
+		matcher = annotErr.item.Matcher
+	}
+	category := category(err)
+	if matcher == "" && category == "" {
+		return false
+	}
+
+	found := false
+	for _, s := range an.suppressions {
+		if s.target != matcher && (category == "" || s.target != category) {
+			continue
+		}
+		if s.from.IsValid() && (pos < s.from || pos > s.to) {
+			continue
+		}
+		s.used = true
+		found = true
+	}
+	return found
+}
+
+// ReportUnusedSuppressions warns about ignore directives that did not
+// suppress any violation.
+func (an *Analyzer) ReportUnusedSuppressions() {
+	for _, s := range an.suppressions {
+		if !s.used {
+			an.logError(an.AnalysisPass.Fset, s.pos, &categoryError{
+				err:      fmt.Errorf("unused suppression: no violation of %s to ignore", s.target),
+				category: "unused-suppression",
+				severity: "warning",
+			})
+		}
+	}
+}
+
+// lineNodes indexes the nodes of a file by the line they start at.
+type lineNodes struct {
+	fset *token.FileSet
+
+	// first is the position of the first node starting at each line, and
+	// end is the end of the largest statement or declaration starting at
+	// it.
+	first map[int]token.Pos
+	end   map[int]token.Pos
+}
+
+func newLineNodes(fset *token.FileSet, f *ast.File) *lineNodes {
+	lines := &lineNodes{
+		fset:  fset,
+		first: make(map[int]token.Pos),
+		end:   make(map[int]token.Pos),
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.File, *ast.CommentGroup, *ast.Comment:
+			return true
+		}
+		line := fset.Position(n.Pos()).Line
+		if first, ok := lines.first[line]; !ok || n.Pos() < first {
+			lines.first[line] = n.Pos()
+		}
+		switch n.(type) {
+		case ast.Stmt, ast.Decl, ast.Spec, *ast.Field:
+			if n.End() > lines.end[line] {
+				lines.end[line] = n.End()
+			}
+		}
+		return true
+	})
+	return lines
+}
+
+// scope returns the positions suppressed by an ignore directive: the line
+// of the comment if it follows code, or else the next line, extended to
+// the statement or declaration starting at that line.
+func (lines *lineNodes) scope(comment *ast.Comment) (token.Pos, token.Pos) {
+	file := lines.fset.File(comment.Pos())
+	line := lines.fset.Position(comment.Pos()).Line
+
+	if first, ok := lines.first[line]; !ok || first > comment.Pos() {
+		// on its own line
+		if line == file.LineCount() {
+			return comment.Pos(), comment.End()
+		}
+		line++
+	}
+
+	from := file.LineStart(line)
+	to := token.Pos(file.Base() + file.Size())
+	if line < file.LineCount() {
+		to = file.LineStart(line+1) - 1
+	}
+	if end := lines.end[line]; end > to {
+		to = end
+	}
+	return from, to
+}
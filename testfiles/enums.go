@@ -0,0 +1,21 @@
+package main
+
+import (
+	"time"
+
+	"github.com/siadat/intertype/testfiles/palette"
+)
+
+// the members of an Enum type of another package come with its annotation
+func _(s palette.Shade) {
+	switch s {
+	case palette.Light:
+	}
+}
+
+// Enum is set for time.Weekday in intertype.yaml
+func _(d time.Weekday) {
+	switch d {
+	case time.Saturday, time.Sunday:
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+func _(ctx context.Context) {
+	var h = context.WithValue
+	h(ctx, 1, 2.0)
+}
+
+func _(ctx context.Context) {
+	h := context.WithValue
+	apply(h)
+	h(ctx, 1, 2.0)
+}
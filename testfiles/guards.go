@@ -0,0 +1,21 @@
+package main
+
+// expressions that look like the ones the passes check, but are not
+
+func _(xs []XX, s string) XX {
+	_ = s[0]
+	return xs[1]
+}
+
+func _() (a, b XX) {
+	return 1, true
+}
+
+type Handler func(x XX)
+
+func _(h Handler, g func(), s string) {
+	h(true)
+	type F func()
+	_ = F(g)
+	_ = append([]byte("a"), s...)
+}
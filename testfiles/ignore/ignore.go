@@ -0,0 +1,40 @@
+//intertype:ignore-file "[Params, 2] context.WithValue" values are decoded by a legacy reader
+package ignore
+
+import "context"
+
+//intertype:ignore-package units the unit conversions are checked in tests
+
+type Key interface {
+	// #intertype {OneOf: [string], category: keys}
+}
+
+type Unit interface {
+	// #intertype {OneOf: [string], category: units}
+}
+
+func _(ctx context.Context) {
+	context.WithValue(ctx, 1, 2) //intertype:ignore "[Params, 1] context.WithValue" generated keys
+
+	//intertype:ignore keys integer keys are being migrated
+	var k Key = 1
+
+	var k2 Key = 2 //intertype:ignore keys
+	var k3 Key = 3 //intertype:ignore "[] github.com/siadat/intertype/testfiles/ignore.Key" a typed matcher
+
+	var u Unit = 1.5
+
+	//intertype:ignore keys nothing to ignore here
+	context.WithValue(ctx, 3, 4.0)
+
+	_, _, _, _ = k, k2, k3, u
+}
+
+func store(fn func(context.Context, interface{}, interface{}) context.Context) {}
+
+func _() {
+	//intertype:ignore func-escape the stored function is only called with string keys
+	store(context.WithValue)
+
+	store(context.WithValue)
+}
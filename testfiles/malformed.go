@@ -0,0 +1,6 @@
+package main
+
+type BadStringer interface {
+	// #intertype {OneOf: [int
+	String() string
+}
@@ -0,0 +1,10 @@
+package palette
+
+// #intertype {Enum: true}
+type Shade int
+
+const (
+	Light Shade = iota
+	Dark
+	shadow // not required outside of this package
+)
@@ -0,0 +1,7 @@
+package main
+
+func _(r Registry, p *int) {
+	// the key is checked against the key annotation of the map
+	r[3] = p
+	r["c"] = p
+}
@@ -0,0 +1,84 @@
+package subsume
+
+type Number interface {
+	// #intertype {OneOf: [int, float64]}
+}
+
+type Integer interface {
+	// #intertype {OneOf: [int]}
+}
+
+type Text interface {
+	// #intertype {OneOf: [string]}
+}
+
+type NotFloat interface {
+	// #intertype {NoneOf: [float64]}
+}
+
+// #intertype param v {OneOf: [int]}
+func takeInt(v interface{}) {}
+
+func _(n Number, i Integer, t Text) {
+	var a Number = i // Integer is a subset of Number
+	var b Integer = n
+	var c Text = i
+	var d NotFloat = i
+	var e NotFloat = n
+	takeInt(i)
+	takeInt(n)
+
+	_ = n.(Integer)
+	_ = n.(Text)
+	_ = i.(Number)
+
+	_, _, _, _, _ = a, b, c, d, e
+}
+
+type Pointer interface {
+	// #intertype {IsPointer: true}
+}
+
+type IntPtr interface {
+	// #intertype {OneOf: ["*int"]}
+}
+
+type timeoutError struct{}
+
+func (*timeoutError) Error() string { return "timeout" }
+
+type Failure interface {
+	// #intertype {OneOf: [error]}
+}
+
+type Timeout interface {
+	// #intertype {OneOf: ["*timeoutError"]}
+}
+
+type Scalar interface {
+	// #intertype {OneOf: [int, float64, string], NoneOf: [string]}
+}
+
+func _(p IntPtr, n Number, f Failure, t Timeout, s Scalar) {
+	var a Pointer = p // all the types of IntPtr are pointers
+	var b Pointer = n
+	var c Failure = t // *timeoutError implements error
+
+	_ = f.(Timeout)
+	_ = s.(Text)
+
+	_, _, _ = a, b, c
+}
+
+func _(n Number, f Failure) {
+	switch n.(type) { // Integer overlaps Number, Text does not
+	case Integer:
+	case Text:
+	default:
+	}
+
+	switch f.(type) { // *timeoutError implements error
+	case Timeout:
+	default:
+	}
+}
@@ -442,3 +442,327 @@ func _() {
 	var nn Deprecated = 3
 	_ = nn
 }
+
+// Store stores val under key.
+//
+// #intertype param val {IsPointer: true}
+// #intertype result 0 {OneOf: [int, float64]}
+func Store(key string, val interface{}) interface{} {
+	if key == "" {
+		return "bad"
+	}
+	return 1
+}
+
+//intertype:param b {OneOf: [string]}
+func Load(a, b interface{}) {}
+
+// #intertype key {OneOf: [string]}
+// #intertype elem {IsPointer: true}
+type Registry map[interface{}]interface{}
+
+func _() {
+	var n int
+	Store("a", &n)
+	Store("b", n)
+	Load(1, "b")
+	Load(1, 2)
+
+	r := Registry{"a": &n, 1: n}
+	r["b"] = n
+	_ = r[2]
+}
+
+type Envelope struct {
+	// #intertype {OneOf: [string, int]}
+	ID      interface{}
+	Payload interface{} // #intertype {IsPointer: true}
+	Meta
+}
+
+type Meta struct {
+	Owner interface{} // #intertype {OneOf: [string]}
+}
+
+type Outer struct {
+	Env *Envelope
+}
+
+func _() {
+	var n int
+	e := &Envelope{ID: 1.5, Payload: n}
+	e.ID = true
+	e.Payload = &n
+	e.Owner = 3
+	e.Meta.Owner = 4
+	o := Outer{Env: e}
+	o.Env.ID = 2.5
+	o.Env.Owner = "ok"
+	_ = []Envelope{{ID: false}}
+	_ = []*Meta{{Owner: 5}}
+}
+
+type Event interface {
+	Kind() string
+	// #intertype {OneOf: [Created, "*Deleted"]}
+}
+
+type Created struct{}
+
+func (Created) Kind() string { return "created" }
+
+type Deleted struct{}
+
+func (*Deleted) Kind() string { return "deleted" }
+
+type Renamed struct{}
+
+func (Renamed) Kind() string { return "renamed" }
+
+// #intertype {OneOf: [string, int]}
+type Key interface{}
+
+type EventAlias = Event
+
+// #intertype {OneOf: [string]}
+type EmptyAlias = interface{}
+
+func _() {
+	var ev Event = Created{}
+	ev = &Deleted{}
+	ev = Renamed{}
+	var ea EventAlias = Renamed{}
+
+	switch ev.(type) {
+	case Created:
+	case nil:
+	}
+
+	switch ev.(type) {
+	case Created, *Deleted, Renamed, nil:
+	}
+
+	var k Key = 1.5
+	_, _, _ = ev, ea, k
+}
+
+// #intertype {Enum: true}
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+const Crimson = Red
+
+func _(c Color) {
+	switch c {
+	case Red, Green:
+	}
+
+	switch c {
+	case Red:
+	default:
+	}
+
+	switch c {
+	case Crimson, Green, Blue:
+	}
+}
+
+func withKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, key, 1.0)
+}
+
+func withKeyTwice(ctx context.Context, key interface{}) context.Context {
+	return withKey(withKey(ctx, key), "inner")
+}
+
+func _(ctx context.Context) {
+	withKey(ctx, "key")
+	withKey(ctx, 42)
+	withKeyTwice(ctx, true)
+}
+
+// #intertype elem {OneOf: [string, int]}
+type Keys []interface{}
+
+func _(ys []XX, vs []interface{}) {
+	_ = []XX{1, "a", true}
+	_ = [...]XX{5: false}
+	_ = Keys{"a", 1, 2.5}
+
+	var keys Keys
+	keys = append(keys, "b", 3.5)
+	keys = append(keys, vs...)
+	keys[0] = false
+
+	var xs []XX
+	xs = append(xs, ys...)
+	copy(keys, vs)
+	copy(xs, ys)
+	_ = xs
+}
+
+// #intertype elem {OneOf: [string]}
+type Messages chan interface{}
+
+type Counter struct {
+	// #intertype {OneOf: [int]}
+	Last interface{}
+}
+
+func _(msgs Messages, ints chan int, m map[string]bool, strs []string) {
+	msgs <- "hello"
+	msgs <- 42
+
+	var x XX
+	var c Counter
+	for x = range m {
+	}
+	for _, x = range m {
+	}
+	for c.Last = range strs {
+	}
+	for _, c.Last = range strs {
+	}
+	for x = range ints {
+	}
+
+	select {
+	case msgs <- true:
+	case x = <-ints:
+	}
+
+	select {
+	case msgs <- "ok":
+	case x = <-msgs:
+	}
+	_ = x
+}
+
+var withValue = context.WithValue
+
+func _(ctx context.Context, decoder *json.Decoder) {
+	f := context.WithValue
+	f(ctx, "key", 1.0)
+	f(ctx, 1, 2.0)
+
+	decode := decoder.Decode
+	decode(3)
+
+	g := context.WithValue
+	g = otherWithValue
+	g(ctx, 1, 2.0)
+
+	apply(context.WithValue)
+	_ = withValue
+}
+
+func apply(fn func(context.Context, interface{}, interface{}) context.Context) {}
+
+func otherWithValue(ctx context.Context, key, val interface{}) context.Context { return ctx }
+
+type myCtx struct {
+	context.Context
+}
+
+func (c *myCtx) Value(key interface{}) interface{} {
+	return c.Context.Value(key)
+}
+
+type promotedCtx struct {
+	context.Context
+}
+
+func _(c *myCtx, p promotedCtx) {
+	c.Value("key")
+	c.Value(true)
+	p.Value(true)
+}
+
+type Namer interface {
+	Name() interface{}
+}
+
+type person struct{}
+
+func (person) Name() interface{} {
+	return 42
+}
+
+type robot struct{}
+
+func (*robot) Name() interface{} {
+	return "r2"
+}
+
+func _() {
+	_ = make([]XX, 3)
+	_ = make(map[XX]XX)
+	_ = new(XX)
+	_ = len([]XX{})
+	_ = XX(true)
+	println("ok", 1)
+	println(1)
+}
+
+func _(err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case error:
+			}
+
+			switch r.(type) {
+			case error, string:
+			}
+		}
+	}()
+
+	defer func() {
+		_ = recover().(string)
+		_ = recover().(error)
+	}()
+
+	panic(err)
+	panic("oops")
+}
+
+type PtrKey interface {
+	// #intertype {OneOf: ["*int", "*string"], IsPointer: true}
+}
+
+func _() {
+	var s string
+	var k PtrKey = s
+	k = &s
+	_ = k
+}
+
+type panicError struct{}
+
+func (*panicError) Error() string { return "panic" }
+
+func _() {
+	panic(&panicError{})
+}
+
+func Lookup(key interface{}) {}
+
+type Level interface {
+	// #intertype {OneOf: [int], severity: info, message: "{annotated} should be an int, not {dynamic}"}
+}
+
+type BadLevel interface {
+	// #intertype {OneOf: [int], severity: fatal}
+}
+
+func _() {
+	Lookup("a")
+	Lookup(1)
+	var l Level = 1.5
+	_ = l
+}
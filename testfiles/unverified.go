@@ -0,0 +1,16 @@
+package main
+
+import "github.com/siadat/intertype/assert"
+
+func _(v interface{}) {
+	var x1 XX = assert.Assert(v)
+	var x2 XX = v.(string)
+	var x3 YY = v.(string)
+	_, _, _ = x1, x2, x3
+}
+
+func _() {
+	// the type of a concrete value is checked
+	var x4 XX = assert.Assert(true)
+	_ = x4
+}
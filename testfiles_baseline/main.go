@@ -0,0 +1,18 @@
+package main
+
+import "context"
+
+type Numeric interface {
+	// #intertype {OneOf: [int, float64]}
+}
+
+func main() {
+	var n Numeric = "old"
+	n = "old"
+	n = "new"
+	_ = n
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, 1, 1.0)
+	_ = ctx
+}
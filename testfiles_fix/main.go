@@ -0,0 +1,43 @@
+package main
+
+import "encoding/json"
+
+type Shape interface {
+	// #intertype {OneOf: [Circle, Square]}
+}
+
+type Circle struct{}
+type Square struct{}
+
+type Config struct {
+	Name string
+	Port int `yaml:"port"`
+}
+
+func area(s Shape) {
+	switch s.(type) {
+	case Circle, int:
+	case string:
+	}
+}
+
+func areas(shapes []Shape) {
+	for _, s := range shapes {
+		switch s.(type) {
+		// round
+		case Circle:
+		case float64:
+			// never
+		case Square:
+			// square
+		}
+	}
+}
+
+func load(data []byte) {
+	var c Config
+	_ = json.Unmarshal(data, c)
+	_, _ = json.Marshal(c)
+}
+
+func main() {}
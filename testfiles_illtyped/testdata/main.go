@@ -0,0 +1,14 @@
+package main
+
+type Numeric interface {
+	// #intertype {OneOf: [int, float64]}
+}
+
+func f(a int) {}
+
+func g(n Numeric) {}
+
+func main() {
+	f(1, 2) // does not type-check, but is analyzed
+	g("a")
+}
@@ -0,0 +1,56 @@
+package main
+
+import "fmt"
+
+type Key interface{}
+
+type Event struct {
+	Name    string
+	Payload interface{}
+}
+
+type Handler func(v interface{})
+
+type User struct{}
+type Order struct{}
+type Invoice struct{}
+type Refund struct{}
+
+func Store(key Key, val interface{}) {}
+
+func Save(v interface{}) {}
+
+func Lookup(id interface{}) interface{} {
+	switch id.(type) {
+	case int:
+	case string:
+	}
+	return "found"
+}
+
+// #intertype {OneOf: [int, string]}
+type ID interface{}
+
+func Forward(v interface{}) {
+	Save(v)
+}
+
+func main() {
+	Store("a", 1)
+	Store("b", 2.5)
+	Store(Key(3), "c")
+
+	Save(&User{})
+	Save(&Order{})
+	Save(&Invoice{})
+	Save(&Refund{})
+
+	var id ID = 1
+	Lookup(id)
+	Lookup(42)
+
+	_ = Event{Name: "start", Payload: []byte("x")}
+
+	var fromJSON interface{}
+	fmt.Println(Event{Payload: fromJSON})
+}
@@ -0,0 +1,7 @@
+package main
+
+func Lookup(key interface{}) {}
+
+func main() {
+	Lookup(1)
+}
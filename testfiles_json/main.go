@@ -0,0 +1,20 @@
+package main
+
+type Key interface{}
+
+// #intertype param key {OneOf: [string]}
+func Put(key Key) {}
+
+type Numeric interface {
+	// #intertype {OneOf: [int, float64], category: numbers, docURL: "https://example.com/numbers"}
+}
+
+func main() {
+	var n Numeric = "one"
+	_ = n
+
+	var v interface{}
+	n = v
+
+	Put(1)
+}
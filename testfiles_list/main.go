@@ -0,0 +1,34 @@
+package main
+
+type Key interface{}
+
+type Event interface {
+	Payload() interface{}
+	Name() string
+}
+
+type Envelope struct {
+	ID      Key
+	Payload interface{}
+	Size    int
+}
+
+type Registry map[interface{}]interface{}
+
+type Keys []interface{}
+
+type Messages chan interface{}
+
+type Names []string
+
+type store struct{}
+
+func (s *store) Put(key string, val interface{}) {}
+
+func (s store) Get(key string) (interface{}, bool) { return nil, false }
+
+func Store(key Key, val interface{}, n int) interface{} { return nil }
+
+func Printf(format string, args ...interface{}) {}
+
+func main() {}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/siadat/intertype/testfiles_sealed/shapes"
+)
+
+// myShape implements shapes.Shape through the embedded shapes.Circle.
+type myShape struct {
+	shapes.Circle
+}
+
+func main() {
+	var s shapes.Shape = shapes.Circle{}
+
+	switch s.(type) {
+	case shapes.Circle:
+	case *shapes.Square:
+	case nil:
+	}
+
+	switch s.(type) {
+	case *shapes.Square:
+	}
+
+	switch s.(type) {
+	case shapes.Circle:
+	default:
+	}
+
+	s = myShape{}
+	shapes.Area(myShape{})
+
+	// a type switch does not need a case for myShape, but can have one
+	switch s.(type) {
+	case shapes.Circle, myShape:
+	case *shapes.Square:
+	case nil:
+	}
+}
@@ -0,0 +1,27 @@
+package shapes
+
+type Shape interface {
+	isShape()
+}
+
+type Circle struct{}
+
+func (Circle) isShape() {}
+
+type Square struct{}
+
+func (*Square) isShape() {}
+
+// Color is not sealed: all of its methods are exported.
+type Color interface {
+	RGB() (uint8, uint8, uint8)
+}
+
+func Area(s Shape) float64 {
+	switch s.(type) {
+	case Circle:
+		return 3.14
+	case nil:
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package main
+
+import "fmt"
+
+type Numeric interface {
+	// #intertype {OneOf: [int, float64]}
+}
+
+func consume(n Numeric) {}
+
+func local() {
+	var v interface{} = "x"
+	var n Numeric = v
+	_ = n
+}
+
+func valid() {
+	var v interface{} = 3
+	var n Numeric = v
+	_ = n
+}
+
+func branches(cond bool) {
+	var v interface{} = 3
+	if cond {
+		v = 3.14
+	} else {
+		v = true
+	}
+	consume(v)
+}
+
+func addressTaken() {
+	var v interface{} = 1
+	p := &v
+	_ = p
+	v = "str"
+	var n Numeric = v
+	_ = n
+}
+
+func asserted(x interface{}) {
+	v := interface{}(x.(string))
+	var n Numeric
+	n = v
+	_ = n
+}
+
+func unknown(x interface{}) {
+	var n Numeric = x
+	_ = n
+	n = fmt.Sprint(x)
+}
+
+func returned() Numeric {
+	var v interface{} = "x"
+	return v
+}
+
+func channel() {
+	ch := make(chan Numeric, 1)
+	var v interface{} = 1
+	v = "x"
+	ch <- v
+}
+
+// #intertype param v {OneOf: [int, float64]}
+func record(v interface{}) {}
+
+func forward(x interface{}) {
+	record(x) // checked at the calls of forward instead
+}
+
+func forwarded() {
+	forward(1)
+	forward("x")
+}
+
+func main() {}
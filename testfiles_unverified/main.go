@@ -0,0 +1,24 @@
+package main
+
+import "github.com/siadat/intertype/assert"
+
+type Numeric interface {
+	// #intertype {OneOf: [int, float64]}
+}
+
+type Named interface {
+	// #intertype {NoneOf: [bool]}
+}
+
+func load() interface{} {
+	return 1
+}
+
+func main() {
+	var n1 Numeric = load()
+	var n2 Numeric = load().(int)
+	var n3 Numeric = assert.Assert(load())
+	var n4 Named = load()
+	var n5 Named = n1
+	_, _, _, _, _ = n1, n2, n3, n4, n5
+}
@@ -11,6 +11,29 @@ func isIncluded(hay string, stack []string) bool {
 	return false
 }
 
+// typesNotIn returns the types of typs that are not in others, besides nil
+// which any interface can contain.
+func typesNotIn(typs, others []string) []string {
+	var extra []string
+	for i := range typs {
+		if typs[i] != "untyped nil" && !isIncluded(typs[i], others) {
+			extra = append(extra, typs[i])
+		}
+	}
+	return extra
+}
+
+// typesIn returns the types of typs that are in others, besides nil.
+func typesIn(typs, others []string) []string {
+	var common []string
+	for i := range typs {
+		if typs[i] != "untyped nil" && isIncluded(typs[i], others) {
+			common = append(common, typs[i])
+		}
+	}
+	return common
+}
+
 func checkImpossibleTypes(badTypes []string, dynamicTypes []types.Type) (extraTypes []string) {
 	var dynTyps []string
 